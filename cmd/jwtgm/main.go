@@ -0,0 +1,438 @@
+// Command jwtgm generates keys, signs, verifies and decodes GM tokens.
+//
+// Usage:
+//
+//	jwtgm genkey -alg GmSM2|ES256K|HSM3
+//	jwtgm sign   -alg GmSM2|ES256K|HSM3 -key key.pem [-kid kid] [-claims claims.json]
+//	jwtgm verify -alg GmSM2|ES256K|HSM3 -key pub.pem [-leeway 0] [-token token.txt]
+//	jwtgm decode [-token token.txt]
+//
+// The claims and the token are read from stdin when the file is empty or "-".
+// The keys of GmSM2 and ES256K are PEM encoded, the key of HSM3 is the
+// file of the base64url secret printed by genkey, the spaces around it
+// are removed. The results are JSON.
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	pubkey_ecdsa "github.com/deatil/go-cryptobin/pubkey/ecdsa"
+	"github.com/deatil/go-jwt/jwt"
+
+	jwtgm "github.com/deatil/go-jwt-gm/jwt"
+)
+
+var (
+	errUnknownAlg    = errors.New("jwtgm: unknown alg")
+	errAlgRequired   = errors.New("jwtgm: -alg is required")
+	errKeyRequired   = errors.New("jwtgm: -key is required")
+	errTokenInvalid  = errors.New("jwtgm: token is invalid")
+	errSecretInvalid = errors.New("jwtgm: HSM3 secret is not base64url")
+)
+
+const usage = `usage: jwtgm <command> [flags]
+
+commands:
+  genkey  generate a key
+  sign    sign the claims
+  verify  verify the token
+  decode  decode the token without verification
+
+run "jwtgm <command> -h" for the flags of the command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command and returns the exit code,
+// 0 is ok, 1 is a failed command and 2 is a usage error.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	cmds := map[string]func([]string, io.Reader, io.Writer, io.Writer) int{
+		"genkey": runGenkey,
+		"sign":   runSign,
+		"verify": runVerify,
+		"decode": runDecode,
+	}
+
+	cmd, ok := cmds[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "jwtgm: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	return cmd(args[1:], stdin, stdout, stderr)
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("jwtgm "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	return fs
+}
+
+// fail writes the error as JSON and returns the exit code 1.
+func fail(stdout io.Writer, err error) int {
+	writeJSON(stdout, map[string]any{
+		"error": err.Error(),
+	})
+
+	return 1
+}
+
+func writeJSON(w io.Writer, v any) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// readInput reads the file, or stdin when the name is empty or "-".
+func readInput(name string, stdin io.Reader) ([]byte, error) {
+	if name == "" || name == "-" {
+		return io.ReadAll(stdin)
+	}
+
+	return os.ReadFile(name)
+}
+
+func encodePEM(typ string, der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{
+		Type:  typ,
+		Bytes: der,
+	}))
+}
+
+func runGenkey(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("genkey", stderr)
+	alg := fs.String("alg", "", "GmSM2, ES256K or HSM3")
+	size := fs.Int("size", 32, "HSM3 secret bytes")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var privateDer, publicDer []byte
+	var err error
+
+	switch *alg {
+	case "GmSM2":
+		var key *sm2.PrivateKey
+		if key, err = sm2.GenerateKey(rand.Reader); err != nil {
+			return fail(stdout, err)
+		}
+
+		if privateDer, err = sm2.MarshalPrivateKey(key); err != nil {
+			return fail(stdout, err)
+		}
+		if publicDer, err = sm2.MarshalPublicKey(&key.PublicKey); err != nil {
+			return fail(stdout, err)
+		}
+	case "ES256K":
+		var key *ecdsa.PrivateKey
+		if key, err = ecdsa.GenerateKey(secp256k1.S256(), rand.Reader); err != nil {
+			return fail(stdout, err)
+		}
+
+		if privateDer, err = pubkey_ecdsa.MarshalPrivateKey(key); err != nil {
+			return fail(stdout, err)
+		}
+		if publicDer, err = pubkey_ecdsa.MarshalPublicKey(&key.PublicKey); err != nil {
+			return fail(stdout, err)
+		}
+	case "HSM3":
+		secret := make([]byte, *size)
+		if _, err = rand.Read(secret); err != nil {
+			return fail(stdout, err)
+		}
+
+		writeJSON(stdout, map[string]any{
+			"alg": *alg,
+			"key": base64.RawURLEncoding.EncodeToString(secret),
+		})
+
+		return 0
+	case "":
+		return fail(stdout, errAlgRequired)
+	default:
+		return fail(stdout, errUnknownAlg)
+	}
+
+	writeJSON(stdout, map[string]any{
+		"alg":         *alg,
+		"private_key": encodePEM("PRIVATE KEY", privateDer),
+		"public_key":  encodePEM("PUBLIC KEY", publicDer),
+	})
+
+	return 0
+}
+
+// readKey returns the DER of the PEM key file, or the HSM3 secret decoded
+// from the base64url of the file.
+func readKey(name string, alg string) ([]byte, error) {
+	if name == "" {
+		return nil, errKeyRequired
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if alg == "HSM3" {
+		secret, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(secret) == 0 {
+			return nil, errSecretInvalid
+		}
+
+		return secret, nil
+	}
+
+	return jwt.ParsePEM(data)
+}
+
+func runSign(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("sign", stderr)
+	alg := fs.String("alg", "", "GmSM2, ES256K or HSM3")
+	keyFile := fs.String("key", "", "private key PEM file, or HSM3 base64url secret file")
+	kid := fs.String("kid", "", "key id header")
+	claimsFile := fs.String("claims", "", "claims JSON file, stdin if empty or -")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *alg == "" {
+		return fail(stdout, errAlgRequired)
+	}
+
+	key, err := readKey(*keyFile, *alg)
+	if err != nil {
+		return fail(stdout, err)
+	}
+
+	data, err := readInput(*claimsFile, stdin)
+	if err != nil {
+		return fail(stdout, err)
+	}
+
+	// keep the number claims as they are
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var claims map[string]any
+	if err := dec.Decode(&claims); err != nil {
+		return fail(stdout, err)
+	}
+
+	header := jwt.TokenHeader{
+		Typ: "JWT",
+		Alg: *alg,
+		Kid: *kid,
+	}
+
+	var tokenString string
+
+	switch *alg {
+	case "GmSM2":
+		var privateKey *sm2.PrivateKey
+		if privateKey, err = jwtgm.ParseSM2PrivateKeyFromDer(key); err != nil {
+			return fail(stdout, err)
+		}
+
+		tokenString, err = jwtgm.SigningMethodGmSM2.New().SignWithHeader(header, claims, privateKey)
+	case "ES256K":
+		var privateKey *ecdsa.PrivateKey
+		if privateKey, err = jwtgm.ParseECPrivateKeyFromDer(key); err != nil {
+			return fail(stdout, err)
+		}
+
+		tokenString, err = jwtgm.SigningMethodES256K.New().SignWithHeader(header, claims, privateKey)
+	case "HSM3":
+		tokenString, err = jwtgm.SigningMethodHSM3.New().SignWithHeader(header, claims, key)
+	default:
+		return fail(stdout, errUnknownAlg)
+	}
+
+	if err != nil {
+		return fail(stdout, err)
+	}
+
+	writeJSON(stdout, map[string]any{
+		"token": tokenString,
+	})
+
+	return 0
+}
+
+// parseSM2PublicKey parses the public key, or the public key of the private key.
+func parseSM2PublicKey(der []byte) (*sm2.PublicKey, error) {
+	publicKey, err := jwtgm.ParseSM2PublicKeyFromDer(der)
+	if err == nil {
+		return publicKey, nil
+	}
+
+	privateKey, err2 := jwtgm.ParseSM2PrivateKeyFromDer(der)
+	if err2 != nil {
+		return nil, err
+	}
+
+	return &privateKey.PublicKey, nil
+}
+
+// parseECPublicKey parses the public key, or the public key of the private key.
+func parseECPublicKey(der []byte) (*ecdsa.PublicKey, error) {
+	publicKey, err := jwtgm.ParseECPublicKeyFromDer(der)
+	if err == nil {
+		return publicKey, nil
+	}
+
+	privateKey, err2 := jwtgm.ParseECPrivateKeyFromDer(der)
+	if err2 != nil {
+		return nil, err
+	}
+
+	return &privateKey.PublicKey, nil
+}
+
+func runVerify(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("verify", stderr)
+	alg := fs.String("alg", "", "expected alg, GmSM2, ES256K or HSM3")
+	keyFile := fs.String("key", "", "public or private key PEM file, or HSM3 base64url secret file")
+	tokenFile := fs.String("token", "", "token file, stdin if empty or -")
+	leeway := fs.Int64("leeway", 0, "leeway seconds of exp, nbf and iat")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *alg == "" {
+		return fail(stdout, errAlgRequired)
+	}
+
+	key, err := readKey(*keyFile, *alg)
+	if err != nil {
+		return fail(stdout, err)
+	}
+
+	data, err := readInput(*tokenFile, stdin)
+	if err != nil {
+		return fail(stdout, err)
+	}
+
+	tokenString := strings.TrimSpace(string(data))
+
+	var parsed *jwt.Token
+
+	switch *alg {
+	case "GmSM2":
+		var publicKey *sm2.PublicKey
+		if publicKey, err = parseSM2PublicKey(key); err != nil {
+			return fail(stdout, err)
+		}
+
+		parsed, err = jwtgm.NewAllowlistParser[*sm2.PrivateKey, *sm2.PublicKey](*alg).Parse(tokenString, publicKey)
+	case "ES256K":
+		var publicKey *ecdsa.PublicKey
+		if publicKey, err = parseECPublicKey(key); err != nil {
+			return fail(stdout, err)
+		}
+
+		parsed, err = jwtgm.NewAllowlistParser[*ecdsa.PrivateKey, *ecdsa.PublicKey](*alg).Parse(tokenString, publicKey)
+	case "HSM3":
+		parsed, err = jwtgm.NewAllowlistParser[[]byte, []byte](*alg).Parse(tokenString, key)
+	default:
+		return fail(stdout, errUnknownAlg)
+	}
+
+	if err == nil {
+		err = jwtgm.ValidateClaims(parsed, time.Now(), *leeway, "", "")
+	}
+
+	result := map[string]any{
+		"valid": err == nil,
+	}
+
+	if err != nil {
+		result["error"] = err.Error()
+	}
+
+	if header, claims, decodeErr := decodeToken(tokenString); decodeErr == nil {
+		result["header"] = header
+		result["claims"] = claims
+	}
+
+	writeJSON(stdout, result)
+
+	if err != nil {
+		return 1
+	}
+
+	return 0
+}
+
+// decodeToken returns the header and the claims without verification.
+func decodeToken(tokenString string) (header map[string]any, claims map[string]any, err error) {
+	t := jwt.NewToken(jwt.JWTEncoder)
+	t.Parse(tokenString)
+
+	if header, err = t.GetHeaders(); err != nil {
+		return nil, nil, err
+	}
+
+	if claims, err = t.GetClaims(); err != nil {
+		return nil, nil, err
+	}
+
+	return header, claims, nil
+}
+
+func runDecode(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("decode", stderr)
+	tokenFile := fs.String("token", "", "token file, stdin if empty or -")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	data, err := readInput(*tokenFile, stdin)
+	if err != nil {
+		return fail(stdout, err)
+	}
+
+	tokenString := strings.TrimSpace(string(data))
+	if strings.Count(tokenString, ".") != 2 {
+		return fail(stdout, errTokenInvalid)
+	}
+
+	header, claims, err := decodeToken(tokenString)
+	if err != nil {
+		return fail(stdout, err)
+	}
+
+	t := jwt.NewToken(jwt.JWTEncoder)
+	t.Parse(tokenString)
+
+	writeJSON(stdout, map[string]any{
+		"header":    header,
+		"claims":    claims,
+		"signature": hex.EncodeToString(t.GetSignature()),
+	})
+
+	return 0
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	jwtgm "github.com/deatil/go-jwt-gm/jwt"
+)
+
+func runJSON(t *testing.T, stdin string, args ...string) (map[string]any, int) {
+	var stdout, stderr bytes.Buffer
+
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+
+	var out map[string]any
+	if stdout.Len() > 0 {
+		if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+			t.Fatalf("%v: %s", err, stdout.String())
+		}
+	}
+
+	return out, code
+}
+
+func writeFile(t *testing.T, dir string, name string, data string) string {
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func Test_Run(t *testing.T) {
+	for _, alg := range []string{"GmSM2", "ES256K", "HSM3"} {
+		t.Run(alg, func(t *testing.T) {
+			dir := t.TempDir()
+
+			key, code := runJSON(t, "", "genkey", "-alg", alg)
+			if code != 0 {
+				t.Fatalf("genkey got %d, %v", code, key)
+			}
+
+			var signKey, verifyKey string
+			if alg == "HSM3" {
+				signKey = writeFile(t, dir, "secret", key["key"].(string)+"\n")
+				verifyKey = signKey
+			} else {
+				signKey = writeFile(t, dir, "key.pem", key["private_key"].(string))
+				verifyKey = writeFile(t, dir, "pub.pem", key["public_key"].(string))
+			}
+
+			signed, code := runJSON(t, `{"sub":"ops","exp":4102444800}`, "sign", "-alg", alg, "-key", signKey, "-kid", "k1")
+			if code != 0 {
+				t.Fatalf("sign got %d, %v", code, signed)
+			}
+
+			tokenString := signed["token"].(string)
+			tokenFile := writeFile(t, dir, "token.txt", tokenString+"\n")
+
+			verified, code := runJSON(t, "", "verify", "-alg", alg, "-key", verifyKey, "-token", tokenFile)
+			if code != 0 || verified["valid"] != true {
+				t.Fatalf("verify got %d, %v", code, verified)
+			}
+
+			claims := verified["claims"].(map[string]any)
+			if claims["sub"] != "ops" || claims["exp"] != float64(4102444800) {
+				t.Errorf("verify claims got %v", claims)
+			}
+
+			header := verified["header"].(map[string]any)
+			if header["alg"] != alg || header["kid"] != "k1" {
+				t.Errorf("verify header got %v", header)
+			}
+
+			decoded, code := runJSON(t, tokenString, "decode")
+			if code != 0 {
+				t.Fatalf("decode got %d, %v", code, decoded)
+			}
+
+			if decoded["claims"].(map[string]any)["sub"] != "ops" || decoded["signature"] == "" {
+				t.Errorf("decode got %v", decoded)
+			}
+
+			// a changed payload fails
+			parts := strings.Split(tokenString, ".")
+			tampered := parts[0] + ".eyJzdWIiOiJyb290In0." + parts[2]
+
+			verified, code = runJSON(t, tampered, "verify", "-alg", alg, "-key", verifyKey)
+			if code != 1 || verified["valid"] != false {
+				t.Errorf("verify tampered got %d, %v", code, verified)
+			}
+		})
+	}
+}
+
+func Test_Run_HSM3Secret(t *testing.T) {
+	dir := t.TempDir()
+
+	key, code := runJSON(t, "", "genkey", "-alg", "HSM3")
+	if code != 0 {
+		t.Fatalf("genkey got %d, %v", code, key)
+	}
+
+	secretFile := writeFile(t, dir, "secret", key["key"].(string)+"\n")
+
+	signed, code := runJSON(t, `{"sub":"ops"}`, "sign", "-alg", "HSM3", "-key", secretFile)
+	if code != 0 {
+		t.Fatalf("sign got %d, %v", code, signed)
+	}
+
+	verified, code := runJSON(t, signed["token"].(string), "verify", "-alg", "HSM3", "-key", secretFile)
+	if code != 0 || verified["valid"] != true {
+		t.Fatalf("verify got %d, %v", code, verified)
+	}
+
+	// the key is the decoded secret, not the base64url text
+	secret, err := base64.RawURLEncoding.DecodeString(key["key"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(secret) != 32 {
+		t.Errorf("secret length got %d, want %d", len(secret), 32)
+	}
+
+	if _, err := jwtgm.SigningMethodHSM3.New().Parse(signed["token"].(string), secret); err != nil {
+		t.Errorf("Parse got %v", err)
+	}
+
+	tokenString, err := jwtgm.SigningMethodHSM3.New().Sign(map[string]string{"sub": "other"}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified, code = runJSON(t, tokenString, "verify", "-alg", "HSM3", "-key", secretFile)
+	if code != 0 || verified["valid"] != true {
+		t.Errorf("verify got %d, %v", code, verified)
+	}
+}
+
+func Test_Run_VerifyExpired(t *testing.T) {
+	dir := t.TempDir()
+	secret := writeFile(t, dir, "secret", base64.RawURLEncoding.EncodeToString([]byte("test-key")))
+
+	signed, code := runJSON(t, `{"exp":1700000000}`, "sign", "-alg", "HSM3", "-key", secret)
+	if code != 0 {
+		t.Fatalf("sign got %d, %v", code, signed)
+	}
+
+	verified, code := runJSON(t, signed["token"].(string), "verify", "-alg", "HSM3", "-key", secret)
+	if code != 1 || verified["error"] != jwtgm.ErrTokenExpired.Error() {
+		t.Errorf("verify got %d, %v", code, verified)
+	}
+}
+
+func Test_Run_VerifyAlg(t *testing.T) {
+	dir := t.TempDir()
+
+	key, _ := runJSON(t, "", "genkey", "-alg", "GmSM2")
+	signKey := writeFile(t, dir, "key.pem", key["private_key"].(string))
+	verifyKey := writeFile(t, dir, "pub.pem", key["public_key"].(string))
+
+	signed, _ := runJSON(t, `{"sub":"ops"}`, "sign", "-alg", "GmSM2", "-key", signKey)
+
+	// the public key PEM is not an HSM3 secret
+	verified, code := runJSON(t, signed["token"].(string), "verify", "-alg", "HSM3", "-key", verifyKey)
+	if code != 1 || verified["error"] != errSecretInvalid.Error() {
+		t.Errorf("verify got %d, %v", code, verified)
+	}
+
+	// the private key verifies with the public key of it
+	verified, code = runJSON(t, signed["token"].(string), "verify", "-alg", "GmSM2", "-key", signKey)
+	if code != 0 {
+		t.Errorf("verify got %d, %v", code, verified)
+	}
+}
+
+func Test_Run_Usage(t *testing.T) {
+	if _, code := runJSON(t, ""); code != 2 {
+		t.Errorf("run got %d, want %d", code, 2)
+	}
+
+	if _, code := runJSON(t, "", "unknown"); code != 2 {
+		t.Errorf("run got %d, want %d", code, 2)
+	}
+
+	out, code := runJSON(t, "", "genkey")
+	if code != 1 || out["error"] != errAlgRequired.Error() {
+		t.Errorf("genkey got %d, %v", code, out)
+	}
+
+	out, code = runJSON(t, "", "genkey", "-alg", "RS256")
+	if code != 1 || out["error"] != errUnknownAlg.Error() {
+		t.Errorf("genkey got %d, %v", code, out)
+	}
+
+	out, code = runJSON(t, "foo", "decode")
+	if code != 1 || out["error"] != errTokenInvalid.Error() {
+		t.Errorf("decode got %d, %v", code, out)
+	}
+}
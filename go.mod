@@ -5,9 +5,14 @@ go 1.21.0
 require (
 	github.com/deatil/go-cryptobin v1.1.1005
 	github.com/deatil/go-jwt v1.0.10010
+	google.golang.org/grpc v1.67.3
 )
 
 require (
 	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/net v0.28.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
 )
@@ -0,0 +1,138 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"strings"
+
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var (
+	ErrAllowlistAlgNone       = errors.New("go-jwt: alg none not allowed")
+	ErrAllowlistAlgNotAllowed = errors.New("go-jwt: alg not in allowlist")
+	ErrAllowlistAlgMismatch   = errors.New("go-jwt: alg not match the key type")
+	ErrAllowlistKeyConfusion  = errors.New("go-jwt: public key used as hmac secret")
+)
+
+// AllowlistParser parses tokens whose header alg is in an explicit allowlist.
+type AllowlistParser[S any, V any] struct {
+	algs    []string
+	encoder jwt.IEncoder
+}
+
+func NewAllowlistParser[S any, V any](algs ...string) *AllowlistParser[S, V] {
+	return &AllowlistParser[S, V]{
+		algs:    algs,
+		encoder: jwt.JWTEncoder,
+	}
+}
+
+// with new encoder
+func (p *AllowlistParser[S, V]) WithEncoder(encoder jwt.IEncoder) *AllowlistParser[S, V] {
+	p.encoder = encoder
+	return p
+}
+
+// Allowed algo names.
+func (p *AllowlistParser[S, V]) Algs() []string {
+	algs := make([]string, len(p.algs))
+	copy(algs, p.algs)
+
+	return algs
+}
+
+// IsAllowed reports whether the alg is in the allowlist.
+func (p *AllowlistParser[S, V]) IsAllowed(alg string) bool {
+	if isNoneAlg(alg) {
+		return false
+	}
+
+	return containsString(p.algs, alg)
+}
+
+// Parse parses the signature and returns the parsed token.
+func (p *AllowlistParser[S, V]) Parse(tokenString string, verifyKey V) (*jwt.Token, error) {
+	if err := CheckTokenSegments(tokenString, p.encoder); err != nil {
+		return nil, err
+	}
+
+	t := jwt.NewToken(p.encoder)
+	t.Parse(tokenString)
+
+	header, err := t.GetHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(header.Typ) > 0 && header.Typ != "JWT" {
+		return nil, jwt.ErrJWTTypeInvalid
+	}
+
+	if isNoneAlg(header.Alg) {
+		return nil, ErrAllowlistAlgNone
+	}
+
+	if !p.IsAllowed(header.Alg) {
+		return nil, ErrAllowlistAlgNotAllowed
+	}
+
+	if err := CheckCompliance(header.Alg); err != nil {
+		return nil, err
+	}
+
+	signer := jwt.GetSigningMethod[S, V](header.Alg)
+	if signer == nil {
+		return nil, ErrAllowlistAlgMismatch
+	}
+
+	if isPublicKeySecret(verifyKey) {
+		return nil, ErrAllowlistKeyConfusion
+	}
+
+	signature := t.GetSignature()
+
+	signingString, err := t.SigningString()
+	if err != nil {
+		return nil, err
+	}
+
+	ok, _ := signer.Verify([]byte(signingString), signature, verifyKey)
+	if !ok {
+		return nil, jwt.ErrJWTVerifyFail
+	}
+
+	return t, nil
+}
+
+func isNoneAlg(alg string) bool {
+	return alg == "" || strings.EqualFold(alg, "none")
+}
+
+// isPublicKeySecret checks a hmac secret is not a PEM or DER public key.
+func isPublicKeySecret(key any) bool {
+	secret, ok := key.([]byte)
+	if !ok {
+		return false
+	}
+
+	if block, _ := pem.Decode(bytes.TrimSpace(secret)); block != nil {
+		if strings.Contains(block.Type, "PUBLIC KEY") ||
+			strings.Contains(block.Type, "CERTIFICATE") {
+			return true
+		}
+
+		secret = block.Bytes
+	}
+
+	if _, err := ParseSM2PublicKeyFromDer(secret); err == nil {
+		return true
+	}
+
+	if _, err := ParseECPublicKeyFromDer(secret); err == nil {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,149 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func Test_AllowlistParser(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := &privateKey.PublicKey
+
+	claims := map[string]string{
+		"aud": "example.com",
+	}
+
+	tokenString, err := SigningMethodGmSM2.New().Sign(claims, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewAllowlistParser[*sm2.PrivateKey, *sm2.PublicKey]("GmSM2")
+	parsed, err := p.Parse(tokenString, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims2, err := parsed.GetClaims()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims2["aud"].(string) != claims["aud"] {
+		t.Errorf("GetClaims aud got %s, want %s", claims2["aud"].(string), claims["aud"])
+	}
+
+	if !p.IsAllowed("GmSM2") {
+		t.Error("IsAllowed GmSM2 fail")
+	}
+	if p.IsAllowed("none") {
+		t.Error("IsAllowed none should fail")
+	}
+}
+
+func Test_AllowlistParser_Errors(t *testing.T) {
+	sm2Key, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(secp256k1.S256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := map[string]string{
+		"aud": "example.com",
+	}
+
+	t.Run("none", func(t *testing.T) {
+		tokenString, err := jwt.SigningMethodNone.New().Sign(claims, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := NewAllowlistParser[[]byte, []byte]("none", "HSM3")
+		_, err = p.Parse(tokenString, nil)
+		if !errors.Is(err, ErrAllowlistAlgNone) {
+			t.Errorf("Parse got %v, want %v", err, ErrAllowlistAlgNone)
+		}
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		tokenString, err := SigningMethodES256K.New().Sign(claims, ecKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := NewAllowlistParser[*ecdsa.PrivateKey, *ecdsa.PublicKey]("GmSM2")
+		_, err = p.Parse(tokenString, &ecKey.PublicKey)
+		if !errors.Is(err, ErrAllowlistAlgNotAllowed) {
+			t.Errorf("Parse got %v, want %v", err, ErrAllowlistAlgNotAllowed)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		tokenString, err := SigningMethodES256K.New().Sign(claims, ecKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := NewAllowlistParser[*sm2.PrivateKey, *sm2.PublicKey]("GmSM2", "ES256K")
+		_, err = p.Parse(tokenString, &sm2Key.PublicKey)
+		if !errors.Is(err, ErrAllowlistAlgMismatch) {
+			t.Errorf("Parse got %v, want %v", err, ErrAllowlistAlgMismatch)
+		}
+	})
+
+	t.Run("key confusion", func(t *testing.T) {
+		pubDer, err := sm2.MarshalPublicKey(&sm2Key.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokenString, err := SigningMethodHSM3.New().Sign(claims, pubDer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := NewAllowlistParser[[]byte, []byte]("HSM3")
+		_, err = p.Parse(tokenString, pubDer)
+		if !errors.Is(err, ErrAllowlistKeyConfusion) {
+			t.Errorf("Parse got %v, want %v", err, ErrAllowlistKeyConfusion)
+		}
+
+		pubPem := []byte("-----BEGIN PUBLIC KEY-----\nMFkwEwYHKoZIzj0CAQYIKoEcz1UBgi0DQgAE+u+X+H+ZEMhxvW7dkTPniE0XRxRC\nG4pSti0wNjHwkNMqrDBsHXb2fteNA2J2U0fvMPidfIXNqcyDzWJkWyfDmQ==\n-----END PUBLIC KEY-----\n")
+		_, err = p.Parse(tokenString, pubPem)
+		if !errors.Is(err, ErrAllowlistKeyConfusion) {
+			t.Errorf("Parse got %v, want %v", err, ErrAllowlistKeyConfusion)
+		}
+	})
+
+	t.Run("verify fail", func(t *testing.T) {
+		tokenString, err := SigningMethodHSM3.New().Sign(claims, []byte("test-key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := NewAllowlistParser[[]byte, []byte]("HSM3")
+		_, err = p.Parse(tokenString, []byte("test-key2"))
+		if !errors.Is(err, jwt.ErrJWTVerifyFail) {
+			t.Errorf("Parse got %v, want %v", err, jwt.ErrJWTVerifyFail)
+		}
+
+		_, err = p.Parse(tokenString, []byte("test-key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package jwt
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/deatil/go-jwt/jwt"
+)
+
+// BatchItem is a token and the key to verify it.
+type BatchItem[V any] struct {
+	Token string
+	Key   V
+}
+
+// BatchResult is the parsed result of the token at Index.
+type BatchResult struct {
+	Index int
+	Token *jwt.Token
+	Err   error
+}
+
+// BatchVerify parses and verifies the tokens with the signing method in a
+// pool of workers, workers less than 1 uses GOMAXPROCS workers.
+// The results are in the same order as items. A method with its own
+// encoder passes it as the encoder, see ParseStrict.
+func BatchVerify[S any, V any](method jwt.JWT[S, V], items []BatchItem[V], workers int, encoder ...jwt.IEncoder) []BatchResult {
+	results := make([]BatchResult, len(items))
+	if len(items) == 0 {
+		return results
+	}
+
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for index := range indexes {
+				item := items[index]
+
+				token, err := ParseStrict(method, item.Token, item.Key, encoder...)
+
+				results[index] = BatchResult{
+					Index: index,
+					Token: token,
+					Err:   err,
+				}
+			}
+		}()
+	}
+
+	for index := range items {
+		indexes <- index
+	}
+	close(indexes)
+
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,144 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func Test_BatchVerify(t *testing.T) {
+	keys := make([]*sm2.PrivateKey, 3)
+	for i := range keys {
+		key, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys[i] = key
+	}
+
+	var items []BatchItem[*sm2.PublicKey]
+	for i := 0; i < 20; i++ {
+		claims := map[string]string{
+			"jti": fmt.Sprintf("%d", i),
+		}
+
+		key := keys[i%len(keys)]
+		tokenString, err := SigningMethodGmSM2.New().Sign(claims, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// every fifth token is checked with a wrong key
+		verifyKey := &key.PublicKey
+		if i%5 == 0 {
+			verifyKey = &keys[(i+1)%len(keys)].PublicKey
+		}
+
+		items = append(items, BatchItem[*sm2.PublicKey]{
+			Token: tokenString,
+			Key:   verifyKey,
+		})
+	}
+
+	results := BatchVerify(SigningMethodGmSM2, items, 4)
+	if len(results) != len(items) {
+		t.Fatalf("BatchVerify got %d results, want %d", len(results), len(items))
+	}
+
+	for i, res := range results {
+		if res.Index != i {
+			t.Errorf("Index got %d, want %d", res.Index, i)
+		}
+
+		if i%5 == 0 {
+			if res.Err != jwt.ErrJWTVerifyFail {
+				t.Errorf("Err %d got %v, want %v", i, res.Err, jwt.ErrJWTVerifyFail)
+			}
+			continue
+		}
+
+		if res.Err != nil {
+			t.Fatalf("Err %d got %v", i, res.Err)
+		}
+
+		claims, err := res.Token.GetClaims()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if claims["jti"].(string) != fmt.Sprintf("%d", i) {
+			t.Errorf("GetClaims jti got %s, want %d", claims["jti"].(string), i)
+		}
+	}
+
+	if got := BatchVerify(SigningMethodGmSM2, nil, 4); len(got) != 0 {
+		t.Errorf("BatchVerify got %d results, want 0", len(got))
+	}
+}
+
+func benchmarkBatchItems[S any, V any](b *testing.B, method jwt.JWT[S, V], signKey S, verifyKey V) []BatchItem[V] {
+	items := make([]BatchItem[V], 256)
+	for i := range items {
+		claims := map[string]string{
+			"jti": fmt.Sprintf("%d", i),
+		}
+
+		tokenString, err := method.New().Sign(claims, signKey)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		items[i] = BatchItem[V]{
+			Token: tokenString,
+			Key:   verifyKey,
+		}
+	}
+
+	return items
+}
+
+func Benchmark_BatchVerify_GmSM2(b *testing.B) {
+	privateKey, _ := sm2.GenerateKey(rand.Reader)
+	items := benchmarkBatchItems(b, SigningMethodGmSM2, privateKey, &privateKey.PublicKey)
+
+	b.Run("Sequential", func(b *testing.B) {
+		p := SigningMethodGmSM2.New()
+		for i := 0; i < b.N; i++ {
+			for _, item := range items {
+				p.Parse(item.Token, item.Key)
+			}
+		}
+	})
+
+	b.Run("Batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BatchVerify(SigningMethodGmSM2, items, 0)
+		}
+	})
+}
+
+func Benchmark_BatchVerify_ES256K(b *testing.B) {
+	privateKey, _ := ecdsa.GenerateKey(secp256k1.S256(), rand.Reader)
+	items := benchmarkBatchItems(b, SigningMethodES256K, privateKey, &privateKey.PublicKey)
+
+	b.Run("Sequential", func(b *testing.B) {
+		p := SigningMethodES256K.New()
+		for i := 0; i < b.N; i++ {
+			for _, item := range items {
+				p.Parse(item.Token, item.Key)
+			}
+		}
+	})
+
+	b.Run("Batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BatchVerify(SigningMethodES256K, items, 0)
+		}
+	})
+}
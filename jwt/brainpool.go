@@ -0,0 +1,45 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"encoding/asn1"
+
+	"github.com/deatil/go-cryptobin/elliptic/brainpool"
+	pubkey_ecdsa "github.com/deatil/go-cryptobin/pubkey/ecdsa"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var (
+	SigningBP256R1 = NewSignECDSA(crypto.SHA256, 32, "BP256R1", brainpool.P256r1())
+	SigningBP384R1 = NewSignECDSA(crypto.SHA384, 48, "BP384R1", brainpool.P384r1())
+	SigningBP512R1 = NewSignECDSA(crypto.SHA512, 64, "BP512R1", brainpool.P512r1())
+
+	SigningMethodBP256R1 = jwt.NewJWT[*ecdsa.PrivateKey, *ecdsa.PublicKey](SigningBP256R1, jwt.JWTEncoder)
+	SigningMethodBP384R1 = jwt.NewJWT[*ecdsa.PrivateKey, *ecdsa.PublicKey](SigningBP384R1, jwt.JWTEncoder)
+	SigningMethodBP512R1 = jwt.NewJWT[*ecdsa.PrivateKey, *ecdsa.PublicKey](SigningBP512R1, jwt.JWTEncoder)
+)
+
+// The RFC 5639 OIDs of the curves, the OIDs of the brainpool package
+// miss the ecStdCurvesAndGeneration arc and are not used by other libraries.
+var (
+	oidNamedCurveBP256R1 = asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 7}
+	oidNamedCurveBP384R1 = asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 11}
+	oidNamedCurveBP512R1 = asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 13}
+)
+
+func init() {
+	pubkey_ecdsa.AddNamedCurve(brainpool.P256r1(), oidNamedCurveBP256R1)
+	pubkey_ecdsa.AddNamedCurve(brainpool.P384r1(), oidNamedCurveBP384R1)
+	pubkey_ecdsa.AddNamedCurve(brainpool.P512r1(), oidNamedCurveBP512R1)
+
+	jwt.RegisterSigningMethod(SigningBP256R1.Alg(), func() any {
+		return SigningBP256R1
+	})
+	jwt.RegisterSigningMethod(SigningBP384R1.Alg(), func() any {
+		return SigningBP384R1
+	})
+	jwt.RegisterSigningMethod(SigningBP512R1.Alg(), func() any {
+		return SigningBP512R1
+	})
+}
@@ -0,0 +1,174 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/elliptic/brainpool"
+	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
+	pubkey_ecdsa "github.com/deatil/go-cryptobin/pubkey/ecdsa"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func Test_SigningBrainpool(t *testing.T) {
+	tests := []struct {
+		signer     *SignES256K
+		method     jwt.JWT[*ecdsa.PrivateKey, *ecdsa.PublicKey]
+		alg        string
+		signLength int
+	}{
+		{SigningBP256R1, SigningMethodBP256R1, "BP256R1", 64},
+		{SigningBP384R1, SigningMethodBP384R1, "BP384R1", 96},
+		{SigningBP512R1, SigningMethodBP512R1, "BP512R1", 128},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			h := tt.signer
+
+			if h.Alg() != tt.alg {
+				t.Errorf("Alg got %s, want %s", h.Alg(), tt.alg)
+			}
+			if h.SignLength() != tt.signLength {
+				t.Errorf("SignLength got %d, want %d", h.SignLength(), tt.signLength)
+			}
+
+			if m := jwt.GetSigningMethod[*ecdsa.PrivateKey, *ecdsa.PublicKey](tt.alg); m == nil || m.Alg() != tt.alg {
+				t.Errorf("GetSigningMethod %s not registered", tt.alg)
+			}
+
+			privateKey, err := ecdsa.GenerateKey(h.Curve, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims := map[string]string{
+				"aud": "example.com",
+				"sub": "foo",
+			}
+
+			tokenString, err := tt.method.New().Sign(claims, privateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsed, err := tt.method.New().Parse(tokenString, &privateKey.PublicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims2, err := parsed.GetClaims()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if claims2["sub"] != "foo" {
+				t.Errorf("GetClaims sub got %v, want %s", claims2["sub"], "foo")
+			}
+
+			// the keys are encoded with the named curve OID
+			der, err := pubkey_ecdsa.MarshalPublicKey(&privateKey.PublicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			publicKey, err := ParseECPublicKeyFromDerWithCurve(der, h.Curve)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !publicKey.Equal(&privateKey.PublicKey) {
+				t.Error("ParseECPublicKeyFromDerWithCurve PublicKey not equal")
+			}
+		})
+	}
+}
+
+func Test_SigningBrainpool_Curve(t *testing.T) {
+	msg := []byte("test-data")
+
+	bp384Key, err := ecdsa.GenerateKey(brainpool.P384r1(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the key of other curves are refused
+	if _, err := SigningBP256R1.Sign(msg, bp384Key); err != ErrSignES256KKeyInvalid {
+		t.Errorf("Sign got %v, want %v", err, ErrSignES256KKeyInvalid)
+	}
+
+	secp256k1Key, err := ecdsa.GenerateKey(secp256k1.S256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := SigningES256K.Sign(msg, secp256k1Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SigningBP256R1.Verify(msg, signed, &secp256k1Key.PublicKey); err != ErrSignES256KKeyInvalid {
+		t.Errorf("Verify got %v, want %v", err, ErrSignES256KKeyInvalid)
+	}
+
+	bp256Key, err := ecdsa.GenerateKey(brainpool.P256r1(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err = SigningBP256R1.Sign(msg, bp256Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SigningES256K.Verify(msg, signed, &bp256Key.PublicKey); err != ErrSignES256KKeyInvalid {
+		t.Errorf("Verify got %v, want %v", err, ErrSignES256KKeyInvalid)
+	}
+}
+
+func Test_ParseBrainpoolKeyFromDer(t *testing.T) {
+	tests := []struct {
+		name   string
+		signer *SignES256K
+	}{
+		{"brainpool256", SigningBP256R1},
+		{"brainpool384", SigningBP384R1},
+		{"brainpool512", SigningBP512R1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pemBytes, err := os.ReadFile(filepath.Join("testdata", tt.name+"_private.pem"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			der, err := jwt.ParsePEM(pemBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			privateKey, err := ParseECPrivateKeyFromDerWithCurve(der, tt.signer.Curve)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := ParseECPrivateKeyFromDerWithCurve(der, secp256k1.S256()); err != ErrECKeyCurveInvalid {
+				t.Errorf("ParseECPrivateKeyFromDerWithCurve got %v, want %v", err, ErrECKeyCurveInvalid)
+			}
+
+			signed, err := tt.signer.Sign([]byte("test-data"), privateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ok, err := tt.signer.Verify([]byte("test-data"), signed, &privateKey.PublicKey)
+			if err != nil || !ok {
+				t.Errorf("Verify got %v, %v", ok, err)
+			}
+		})
+	}
+}
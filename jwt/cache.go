@@ -0,0 +1,242 @@
+package jwt
+
+import (
+	"container/list"
+	"crypto/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/deatil/go-cryptobin/hash/sm3"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+// TokenCacheStats is the metrics of the TokenCache.
+type TokenCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Len       int
+}
+
+type tokenCacheEntry struct {
+	key   [sm3.Size]byte
+	token *jwt.Token
+
+	// expires is zero for the token without exp and the max TTL
+	expires time.Time
+}
+
+// TokenCache is a LRU cache of verified tokens keyed by the SM3 digest
+// of the compact token. The entry expires at the token exp or after
+// the max TTL, the earlier one. A max TTL not positive is no max TTL,
+// the entry expires at the token exp only. It is safe for concurrent use.
+type TokenCache struct {
+	mu      sync.Mutex
+	size    int
+	maxTTL  time.Duration
+	entries *list.List
+	items   map[[sm3.Size]byte]*list.Element
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+
+	// now returns the time the entries expire at.
+	now func() time.Time
+}
+
+func NewTokenCache(size int, maxTTL time.Duration) *TokenCache {
+	if size < 1 {
+		size = 1
+	}
+
+	return &TokenCache{
+		size:    size,
+		maxTTL:  maxTTL,
+		entries: list.New(),
+		items:   make(map[[sm3.Size]byte]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// Get returns the cached token.
+func (c *TokenCache) Get(key [sm3.Size]byte) (*jwt.Token, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	entry := elem.Value.(*tokenCacheEntry)
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		c.removeElement(elem)
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.entries.MoveToFront(elem)
+	c.hits.Add(1)
+
+	return entry.token, true
+}
+
+// Add adds the verified token to the cache.
+func (c *TokenCache) Add(key [sm3.Size]byte, token *jwt.Token) {
+	now := c.now()
+
+	var expires time.Time
+	if c.maxTTL > 0 {
+		expires = now.Add(c.maxTTL)
+	}
+
+	claims, err := token.GetClaims()
+	if err != nil {
+		return
+	}
+
+	if _, ok := claims["exp"]; ok {
+		exp, err := claims.GetExpirationTime()
+		if err != nil {
+			return
+		}
+
+		if exp != nil && (expires.IsZero() || exp.Time.Before(expires)) {
+			expires = exp.Time
+		}
+	}
+
+	if !expires.IsZero() && !now.Before(expires) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*tokenCacheEntry)
+		entry.token = token
+		entry.expires = expires
+
+		c.entries.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.entries.PushFront(&tokenCacheEntry{
+		key:     key,
+		token:   token,
+		expires: expires,
+	})
+
+	for c.entries.Len() > c.size {
+		c.removeElement(c.entries.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// Purge removes all the cached tokens.
+func (c *TokenCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries.Init()
+	c.items = make(map[[sm3.Size]byte]*list.Element)
+}
+
+// Len returns the number of the cached tokens.
+func (c *TokenCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries.Len()
+}
+
+// Stats returns the hit and miss metrics.
+func (c *TokenCache) Stats() TokenCacheStats {
+	return TokenCacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Len:       c.Len(),
+	}
+}
+
+func (c *TokenCache) removeElement(elem *list.Element) {
+	c.entries.Remove(elem)
+	delete(c.items, elem.Value.(*tokenCacheEntry).key)
+}
+
+// CachedParser parses tokens with the signing method and the verify key,
+// and caches the verified tokens. The cache can be shared by parsers,
+// the cache keys of every parser are different.
+type CachedParser[S any, V any] struct {
+	jwt     *jwt.JWT[S, V]
+	key     V
+	cache   *TokenCache
+	salt    [16]byte
+	encoder jwt.IEncoder
+}
+
+// NewCachedParser returns the CachedParser, the error is of the random
+// salt of the cache keys.
+func NewCachedParser[S any, V any](method jwt.JWT[S, V], verifyKey V, cache *TokenCache) (*CachedParser[S, V], error) {
+	p := &CachedParser[S, V]{
+		jwt:   method.New(),
+		key:   verifyKey,
+		cache: cache,
+	}
+
+	if _, err := rand.Read(p.salt[:]); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// with new encoder, the encoder of the method can not be read, a method
+// with its own encoder sets it here.
+func (p *CachedParser[S, V]) WithEncoder(encoder jwt.IEncoder) *CachedParser[S, V] {
+	p.jwt.WithEncoder(encoder)
+	p.encoder = encoder
+	return p
+}
+
+// Parse returns the cached token, or parses and verifies the token and
+// validates the exp, nbf and iat claims at the time of the cache.
+// The returned token is shared, do not change it.
+func (p *CachedParser[S, V]) Parse(tokenString string) (*jwt.Token, error) {
+	if err := CheckTokenSegments(tokenString, p.encoder); err != nil {
+		return nil, err
+	}
+
+	key := p.cacheKey(tokenString)
+
+	if token, ok := p.cache.Get(key); ok {
+		return token, nil
+	}
+
+	token, err := p.jwt.Parse(tokenString, p.key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateClaims(token, p.cache.now(), 0, "", ""); err != nil {
+		return nil, err
+	}
+
+	p.cache.Add(key, token)
+
+	return token, nil
+}
+
+func (p *CachedParser[S, V]) cacheKey(tokenString string) (key [sm3.Size]byte) {
+	h := sm3.New()
+	h.Write(p.salt[:])
+	h.Write([]byte(tokenString))
+	h.Sum(key[:0])
+
+	return
+}
@@ -0,0 +1,273 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func Test_CachedParser(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := map[string]string{
+		"aud": "example.com",
+	}
+
+	tokenString, err := SigningMethodGmSM2.New().Sign(claims, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewTokenCache(10, time.Minute)
+	p, err := NewCachedParser(SigningMethodGmSM2, &privateKey.PublicKey, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		parsed, err := p.Parse(tokenString)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		claims2, err := parsed.GetClaims()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims2["aud"].(string) != claims["aud"] {
+			t.Errorf("GetClaims aud got %s, want %s", claims2["aud"].(string), claims["aud"])
+		}
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Len != 1 {
+		t.Errorf("Stats got %+v", stats)
+	}
+
+	// failed tokens are not cached
+	otherKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2, err := NewCachedParser(SigningMethodGmSM2, &otherKey.PublicKey, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = p2.Parse(tokenString); err != jwt.ErrJWTVerifyFail {
+			t.Errorf("Parse got %v, want %v", err, jwt.ErrJWTVerifyFail)
+		}
+	}
+
+	if cache.Len() != 1 {
+		t.Errorf("Len got %d, want %d", cache.Len(), 1)
+	}
+}
+
+func Test_TokenCache_Expires(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	cache := NewTokenCache(10, time.Hour)
+	cache.now = func() time.Time {
+		return now
+	}
+
+	key := []byte("test-key")
+	p, err := NewCachedParser(SigningMethodHSM3, key, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(claims map[string]any) string {
+		tokenString, err := SigningMethodHSM3.New().Sign(claims, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return tokenString
+	}
+
+	withExp := sign(map[string]any{"exp": now.Add(time.Minute).Unix()})
+	noExp := sign(map[string]any{"sub": "foo"})
+	expired := sign(map[string]any{"exp": now.Add(-time.Minute).Unix()})
+
+	for _, tokenString := range []string{withExp, noExp} {
+		if _, err := p.Parse(tokenString); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the expired token is refused, not only not cached
+	if _, err := p.Parse(expired); err != ErrTokenExpired {
+		t.Errorf("Parse got %v, want %v", err, ErrTokenExpired)
+	}
+
+	if cache.Len() != 2 {
+		t.Errorf("Len got %d, want %d", cache.Len(), 2)
+	}
+
+	// the token exp is earlier than the max TTL
+	now = now.Add(2 * time.Minute)
+	if _, err := p.Parse(withExp); err != ErrTokenExpired {
+		t.Errorf("Parse got %v, want %v", err, ErrTokenExpired)
+	}
+	p.Parse(noExp)
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Len != 1 {
+		t.Errorf("Stats got %+v", stats)
+	}
+
+	// the max TTL
+	now = now.Add(2 * time.Hour)
+	p.Parse(noExp)
+
+	stats = cache.Stats()
+	if stats.Hits != 1 || stats.Len != 1 {
+		t.Errorf("Stats got %+v", stats)
+	}
+
+	cache.Purge()
+	if cache.Len() != 0 {
+		t.Errorf("Len got %d, want %d", cache.Len(), 0)
+	}
+}
+
+func Test_TokenCache_NoMaxTTL(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	// no max TTL, the entries expire at the token exp only
+	cache := NewTokenCache(10, 0)
+	cache.now = func() time.Time {
+		return now
+	}
+
+	key := []byte("test-key")
+	p, err := NewCachedParser(SigningMethodHSM3, key, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withExp, err := SigningMethodHSM3.New().Sign(map[string]any{"exp": now.Add(time.Minute).Unix()}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noExp, err := SigningMethodHSM3.New().Sign(map[string]any{"sub": "foo"}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tokenString := range []string{withExp, noExp} {
+		if _, err := p.Parse(tokenString); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if cache.Len() != 2 {
+		t.Errorf("Len got %d, want %d", cache.Len(), 2)
+	}
+
+	now = now.Add(24 * time.Hour)
+	if _, err := p.Parse(noExp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(withExp); err != ErrTokenExpired {
+		t.Errorf("Parse got %v, want %v", err, ErrTokenExpired)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Len != 1 {
+		t.Errorf("Stats got %+v", stats)
+	}
+}
+
+func Test_TokenCache_Evict(t *testing.T) {
+	key := []byte("test-key")
+
+	cache := NewTokenCache(2, time.Hour)
+	p, err := NewCachedParser(SigningMethodHSM3, key, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tokens []string
+	for i := 0; i < 3; i++ {
+		tokenString, err := SigningMethodHSM3.New().Sign(map[string]string{"jti": fmt.Sprintf("%d", i)}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens = append(tokens, tokenString)
+	}
+
+	p.Parse(tokens[0])
+	p.Parse(tokens[1])
+	p.Parse(tokens[0])
+	p.Parse(tokens[2])
+
+	stats := cache.Stats()
+	if stats.Evictions != 1 || stats.Len != 2 {
+		t.Errorf("Stats got %+v", stats)
+	}
+
+	// tokens[1] is the least recently used
+	p.Parse(tokens[0])
+	p.Parse(tokens[1])
+
+	stats = cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 4 {
+		t.Errorf("Stats got %+v", stats)
+	}
+}
+
+func Test_CachedParser_Concurrent(t *testing.T) {
+	key := []byte("test-key")
+
+	cache := NewTokenCache(8, time.Hour)
+	p, err := NewCachedParser(SigningMethodHSM3, key, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tokens []string
+	for i := 0; i < 16; i++ {
+		tokenString, err := SigningMethodHSM3.New().Sign(map[string]string{"jti": fmt.Sprintf("%d", i)}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens = append(tokens, tokenString)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				if _, err := p.Parse(tokens[(i+j)%len(tokens)]); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	stats := cache.Stats()
+	if stats.Hits+stats.Misses != 800 || stats.Len > 8 {
+		t.Errorf("Stats got %+v", stats)
+	}
+}
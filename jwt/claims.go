@@ -0,0 +1,46 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var (
+	ErrTokenExpired     = errors.New("go-jwt: token is expired")
+	ErrTokenNotValidYet = errors.New("go-jwt: token is not valid yet")
+	ErrTokenUsedEarly   = errors.New("go-jwt: token is issued in the future")
+	ErrAudienceInvalid  = errors.New("go-jwt: token audience invalid")
+	ErrIssuerInvalid    = errors.New("go-jwt: token issuer invalid")
+)
+
+// ValidateClaims checks the exp, nbf and iat claims at now with the leeway
+// seconds, and the aud and iss claims when audience and issuer are not
+// empty. The claims not in the token are not checked.
+func ValidateClaims(token *jwt.Token, now time.Time, leeway int64, audience string, issuer string) error {
+	v, err := jwt.NewValidator(token)
+	if err != nil {
+		return err
+	}
+
+	v.WithLeeway(leeway)
+
+	// the validator compares nbf and iat strictly
+	unix := now.Unix()
+
+	switch {
+	case v.IsExpired(unix):
+		return ErrTokenExpired
+	case !v.IsMinimumTimeBefore(unix + 1):
+		return ErrTokenNotValidYet
+	case !v.HasBeenIssuedBefore(unix + 1):
+		return ErrTokenUsedEarly
+	case audience != "" && !v.IsPermittedFor(audience):
+		return ErrAudienceInvalid
+	case issuer != "" && !v.HasBeenIssuedBy(issuer):
+		return ErrIssuerInvalid
+	}
+
+	return nil
+}
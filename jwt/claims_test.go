@@ -0,0 +1,59 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func Test_ValidateClaims(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	key := []byte("test-key")
+
+	token := func(claims map[string]any) *jwt.Token {
+		tokenString, err := SigningMethodHSM3.New().Sign(claims, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := SigningMethodHSM3.New().Parse(tokenString, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return parsed
+	}
+
+	unix := now.Unix()
+
+	tests := []struct {
+		name     string
+		claims   map[string]any
+		leeway   int64
+		audience string
+		issuer   string
+		want     error
+	}{
+		{"no claims", map[string]any{}, 0, "", "", nil},
+		{"valid", map[string]any{"exp": unix + 1, "nbf": unix, "iat": unix}, 0, "", "", nil},
+		{"expired", map[string]any{"exp": unix}, 0, "", "", ErrTokenExpired},
+		{"expired in leeway", map[string]any{"exp": unix - 5}, 10, "", "", nil},
+		{"expired after leeway", map[string]any{"exp": unix - 10}, 10, "", "", ErrTokenExpired},
+		{"nbf", map[string]any{"nbf": unix + 1}, 0, "", "", ErrTokenNotValidYet},
+		{"nbf in leeway", map[string]any{"nbf": unix + 10}, 10, "", "", nil},
+		{"iat", map[string]any{"iat": unix + 1}, 0, "", "", ErrTokenUsedEarly},
+		{"iat in leeway", map[string]any{"iat": unix + 10}, 10, "", "", nil},
+		{"aud", map[string]any{"aud": "example.com"}, 0, "example.com", "", nil},
+		{"aud invalid", map[string]any{"aud": "example.org"}, 0, "example.com", "", ErrAudienceInvalid},
+		{"iss", map[string]any{"iss": "issuer"}, 0, "", "issuer", nil},
+		{"iss invalid", map[string]any{"iss": "other"}, 0, "", "issuer", ErrIssuerInvalid},
+	}
+
+	for _, tt := range tests {
+		err := ValidateClaims(token(tt.claims), now, tt.leeway, tt.audience, tt.issuer)
+		if err != tt.want {
+			t.Errorf("%s: ValidateClaims got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
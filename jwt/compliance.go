@@ -0,0 +1,224 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var (
+	// GM compliance profile, only allow GM algos.
+	ComplianceGM = NewComplianceProfile("GM", "GmSM2", "HSM3")
+)
+
+var ErrComplianceAlgNotPermitted = errors.New("go-jwt: alg not permitted by compliance profile")
+
+// active compliance profile, nil is no profile.
+var activeCompliance atomic.Pointer[ComplianceProfile]
+
+// the registered signers replaced with ComplianceSigner, they are
+// registered again when the profile is disabled.
+var (
+	wrappedSignersMu sync.Mutex
+	wrappedSigners   = make(map[string]any)
+)
+
+// ComplianceProfile restricts the algos that can be used to sign or verify.
+type ComplianceProfile struct {
+	Name string
+	algs []string
+}
+
+func NewComplianceProfile(name string, algs ...string) *ComplianceProfile {
+	permitted := make([]string, len(algs))
+	copy(permitted, algs)
+
+	return &ComplianceProfile{
+		Name: name,
+		algs: permitted,
+	}
+}
+
+// Algs returns the algo names permitted by the profile.
+func (p *ComplianceProfile) Algs() []string {
+	algs := make([]string, len(p.algs))
+	copy(algs, p.algs)
+
+	return algs
+}
+
+// IsPermitted reports whether the alg is permitted by the profile.
+func (p *ComplianceProfile) IsPermitted(alg string) bool {
+	return containsString(p.algs, alg)
+}
+
+// EnableCompliance enables the compliance profile for the process.
+// The signing methods registered with jwt.RegisterSigningMethod are
+// wrapped with ComplianceSigner, so jwt.Parse and the signers of
+// jwt.GetSigningMethod refuse the algos not permitted, and the signers
+// returned are *ComplianceSigner until DisableCompliance. The go-jwt
+// globals as jwt.SigningMethodHS256 hold their signers and are not
+// checked, and the methods registered after enabling are not wrapped.
+func EnableCompliance(p *ComplianceProfile) {
+	activeCompliance.Store(p)
+
+	if p != nil {
+		wrapRegisteredSigners()
+	}
+}
+
+// DisableCompliance disables the enabled compliance profile and
+// registers the signers wrapped by EnableCompliance again.
+func DisableCompliance() {
+	activeCompliance.Store(nil)
+
+	wrappedSignersMu.Lock()
+	defer wrappedSignersMu.Unlock()
+
+	for alg, signer := range wrappedSigners {
+		signer := signer
+		jwt.RegisterSigningMethod(alg, func() any {
+			return signer
+		})
+	}
+
+	clear(wrappedSigners)
+}
+
+// GetCompliance returns the enabled compliance profile, or nil.
+func GetCompliance() *ComplianceProfile {
+	return activeCompliance.Load()
+}
+
+// CheckCompliance returns an error if the alg is not permitted
+// by the enabled compliance profile.
+func CheckCompliance(alg string) error {
+	p := activeCompliance.Load()
+	if p != nil && !p.IsPermitted(alg) {
+		return ErrComplianceAlgNotPermitted
+	}
+
+	return nil
+}
+
+// PermittedAlgs returns the registered algo names that can be used
+// under the enabled compliance profile.
+func PermittedAlgs() []string {
+	var algs []string
+	for _, alg := range jwt.GetSigningMethodAlgs() {
+		if CheckCompliance(alg) == nil {
+			algs = append(algs, alg)
+		}
+	}
+
+	sort.Strings(algs)
+
+	return algs
+}
+
+// ParseCompliant parses the signature like jwt.Parse,
+// and refuses the algos not permitted by the compliance profile.
+func ParseCompliant[S any, V any](tokenString string, key V, encoder ...jwt.IEncoder) (*jwt.Token, error) {
+	if err := CheckTokenSegments(tokenString, encoder...); err != nil {
+		return nil, err
+	}
+
+	header, err := jwt.GetTokenHeader(tokenString, encoder...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckCompliance(header.Alg); err != nil {
+		return nil, err
+	}
+
+	return jwt.Parse[S, V](tokenString, key, encoder...)
+}
+
+// ComplianceSigner wraps a signer and checks the compliance profile
+// before sign and verify.
+type ComplianceSigner[S any, V any] struct {
+	signer jwt.ISigner[S, V]
+}
+
+func NewComplianceSigner[S any, V any](signer jwt.ISigner[S, V]) *ComplianceSigner[S, V] {
+	return &ComplianceSigner[S, V]{
+		signer: signer,
+	}
+}
+
+// Signer algo name.
+func (s *ComplianceSigner[S, V]) Alg() string {
+	return s.signer.Alg()
+}
+
+// Signer signed bytes length.
+func (s *ComplianceSigner[S, V]) SignLength() int {
+	return s.signer.SignLength()
+}
+
+// Sign implements token signing for the Signer.
+func (s *ComplianceSigner[S, V]) Sign(msg []byte, key S) ([]byte, error) {
+	if err := CheckCompliance(s.signer.Alg()); err != nil {
+		return nil, err
+	}
+
+	return s.signer.Sign(msg, key)
+}
+
+// Verify implements token verification for the Signer.
+func (s *ComplianceSigner[S, V]) Verify(msg []byte, signature []byte, key V) (bool, error) {
+	if err := CheckCompliance(s.signer.Alg()); err != nil {
+		return false, err
+	}
+
+	return s.signer.Verify(msg, signature, key)
+}
+
+// wrapRegisteredSigners wraps the registered signers of the go-jwt key
+// types and the SM2 keys with ComplianceSigner.
+func wrapRegisteredSigners() {
+	wrappedSignersMu.Lock()
+	defer wrappedSignersMu.Unlock()
+
+	for _, alg := range jwt.GetSigningMethodAlgs() {
+		switch {
+		case wrapRegisteredSigner[[]byte, []byte](alg):
+		case wrapRegisteredSigner[*rsa.PrivateKey, *rsa.PublicKey](alg):
+		case wrapRegisteredSigner[*ecdsa.PrivateKey, *ecdsa.PublicKey](alg):
+		case wrapRegisteredSigner[ed25519.PrivateKey, ed25519.PublicKey](alg):
+		case wrapRegisteredSigner[*sm2.PrivateKey, *sm2.PublicKey](alg):
+		}
+	}
+}
+
+// wrapRegisteredSigner registers the signer of the alg wrapped with
+// ComplianceSigner, it reports whether the signer has the key types.
+func wrapRegisteredSigner[S any, V any](alg string) bool {
+	signer := jwt.GetSigningMethod[S, V](alg)
+	if signer == nil {
+		return false
+	}
+
+	// the signers checking the profile themselves
+	switch any(signer).(type) {
+	case *ComplianceSigner[S, V], *SignGmSM2, *SignES256K, *SignHSM3:
+		return true
+	}
+
+	wrappedSigners[alg] = signer
+
+	wrapped := NewComplianceSigner[S, V](signer)
+	jwt.RegisterSigningMethod(alg, func() any {
+		return wrapped
+	})
+
+	return true
+}
@@ -0,0 +1,184 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func Test_ComplianceProfile(t *testing.T) {
+	p := ComplianceGM
+
+	if p.Name != "GM" {
+		t.Errorf("Name got %s, want %s", p.Name, "GM")
+	}
+
+	if !p.IsPermitted("GmSM2") {
+		t.Error("IsPermitted GmSM2 fail")
+	}
+	if !p.IsPermitted("HSM3") {
+		t.Error("IsPermitted HSM3 fail")
+	}
+	if p.IsPermitted("ES256K") {
+		t.Error("IsPermitted ES256K should fail")
+	}
+	if p.IsPermitted("HS256") {
+		t.Error("IsPermitted HS256 should fail")
+	}
+}
+
+func Test_EnableCompliance(t *testing.T) {
+	EnableCompliance(ComplianceGM)
+	defer DisableCompliance()
+
+	if GetCompliance() != ComplianceGM {
+		t.Error("GetCompliance fail")
+	}
+
+	algs := PermittedAlgs()
+	want := []string{"GmSM2", "HSM3"}
+	if !reflect.DeepEqual(algs, want) {
+		t.Errorf("PermittedAlgs got %v, want %v", algs, want)
+	}
+
+	claims := map[string]string{
+		"aud": "example.com",
+	}
+
+	t.Run("ES256K", func(t *testing.T) {
+		privateKey, err := ecdsa.GenerateKey(secp256k1.S256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = SigningMethodES256K.New().Sign(claims, privateKey)
+		if !errors.Is(err, ErrComplianceAlgNotPermitted) {
+			t.Errorf("Sign got %v, want %v", err, ErrComplianceAlgNotPermitted)
+		}
+
+		_, err = SigningES256K.Verify([]byte("test-data"), make([]byte, 64), &privateKey.PublicKey)
+		if !errors.Is(err, ErrComplianceAlgNotPermitted) {
+			t.Errorf("Verify got %v, want %v", err, ErrComplianceAlgNotPermitted)
+		}
+	})
+
+	t.Run("HS256", func(t *testing.T) {
+		key := []byte("test-key")
+
+		tokenString, err := jwt.SigningMethodHS256.New().Sign(claims, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ParseCompliant[[]byte, []byte](tokenString, key)
+		if !errors.Is(err, ErrComplianceAlgNotPermitted) {
+			t.Errorf("ParseCompliant got %v, want %v", err, ErrComplianceAlgNotPermitted)
+		}
+
+		s := jwt.NewJWT[[]byte, []byte](NewComplianceSigner[[]byte, []byte](jwt.SigningHS256), jwt.JWTEncoder)
+		_, err = s.Sign(claims, key)
+		if !errors.Is(err, ErrComplianceAlgNotPermitted) {
+			t.Errorf("Sign got %v, want %v", err, ErrComplianceAlgNotPermitted)
+		}
+	})
+
+	t.Run("GmSM2", func(t *testing.T) {
+		privateKey, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokenString, err := SigningMethodGmSM2.New().Sign(claims, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ParseCompliant[*sm2.PrivateKey, *sm2.PublicKey](tokenString, &privateKey.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("HSM3", func(t *testing.T) {
+		key := []byte("test-key")
+
+		tokenString, err := SigningMethodHSM3.New().Sign(claims, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ParseCompliant[[]byte, []byte](tokenString, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func Test_DisableCompliance(t *testing.T) {
+	EnableCompliance(NewComplianceProfile("test", "GmSM2"))
+	DisableCompliance()
+
+	if GetCompliance() != nil {
+		t.Error("GetCompliance should be nil")
+	}
+
+	if err := CheckCompliance("ES256K"); err != nil {
+		t.Errorf("CheckCompliance got %v", err)
+	}
+}
+
+func Test_EnableCompliance_Registered(t *testing.T) {
+	key := []byte("test-key")
+
+	claims := map[string]string{
+		"aud": "example.com",
+	}
+
+	tokenString, err := jwt.SigningMethodHS256.New().Sign(claims, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	EnableCompliance(ComplianceGM)
+
+	// jwt.Parse returns the verify error as jwt.ErrJWTVerifyFail
+	_, err = jwt.Parse[[]byte, []byte](tokenString, key)
+	if !errors.Is(err, jwt.ErrJWTVerifyFail) {
+		t.Errorf("Parse got %v, want %v", err, jwt.ErrJWTVerifyFail)
+	}
+
+	signer := jwt.GetSigningMethod[[]byte, []byte]("HS256")
+	if _, err := signer.Sign([]byte("test-data"), key); !errors.Is(err, ErrComplianceAlgNotPermitted) {
+		t.Errorf("Sign got %v, want %v", err, ErrComplianceAlgNotPermitted)
+	}
+
+	// the permitted algos are not changed
+	hsm3Token, err := SigningMethodHSM3.New().Sign(claims, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := jwt.Parse[[]byte, []byte](hsm3Token, key); err != nil {
+		t.Errorf("Parse HSM3 got %v", err)
+	}
+
+	DisableCompliance()
+
+	if _, err := jwt.Parse[[]byte, []byte](tokenString, key); err != nil {
+		t.Errorf("Parse after DisableCompliance got %v", err)
+	}
+
+	// the go-jwt signers are registered again
+	if _, ok := jwt.GetSigningMethod[[]byte, []byte]("HS256").(*jwt.SignHmac); !ok {
+		t.Error("GetSigningMethod HS256 after DisableCompliance should be *jwt.SignHmac")
+	}
+	if _, ok := jwt.GetSigningMethod[[]byte, []byte]("HSM3").(*SignHSM3); !ok {
+		t.Error("GetSigningMethod HSM3 should be *SignHSM3")
+	}
+}
@@ -0,0 +1,43 @@
+package jwt
+
+import (
+	"context"
+
+	"github.com/deatil/go-jwt/jwt"
+)
+
+type contextKey struct{}
+
+type contextValue struct {
+	token  *jwt.Token
+	claims any
+}
+
+// NewContext returns the context with the token and the claims.
+func NewContext[C any](ctx context.Context, token *jwt.Token, claims *C) context.Context {
+	return context.WithValue(ctx, contextKey{}, contextValue{
+		token:  token,
+		claims: claims,
+	})
+}
+
+// ClaimsFromContext returns the claims of the context.
+func ClaimsFromContext[C any](ctx context.Context) (*C, bool) {
+	value, ok := ctx.Value(contextKey{}).(contextValue)
+	if !ok {
+		return nil, false
+	}
+
+	claims, ok := value.claims.(*C)
+	return claims, ok
+}
+
+// TokenFromContext returns the token of the context.
+func TokenFromContext(ctx context.Context) (*jwt.Token, bool) {
+	value, ok := ctx.Value(contextKey{}).(contextValue)
+	if !ok {
+		return nil, false
+	}
+
+	return value.token, true
+}
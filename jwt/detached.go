@@ -0,0 +1,298 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var (
+	DetachedGmSM2  = NewDetached[*sm2.PrivateKey, *sm2.PublicKey](SigningGmSM2, jwt.JWTEncoder)
+	DetachedES256K = NewDetached[*ecdsa.PrivateKey, *ecdsa.PublicKey](SigningES256K, jwt.JWTEncoder)
+	DetachedHSM3   = NewDetached[[]byte, []byte](SigningHSM3, jwt.JWTEncoder)
+)
+
+var (
+	ErrDetachedTokenInvalid = errors.New("go-jwt: detached token invalid")
+	ErrDetachedCritInvalid  = errors.New("go-jwt: detached token crit header invalid")
+)
+
+// detached token header with RFC 7797 b64 param.
+type detachedHeader struct {
+	Alg  string   `json:"alg"`
+	Kid  string   `json:"kid,omitempty"`
+	B64  *bool    `json:"b64,omitempty"`
+	Crit []string `json:"crit,omitempty"`
+}
+
+// readerSigner is the signer that hashes the message read from r incrementally.
+type readerSigner[S any, V any] interface {
+	SignReader(r io.Reader, key S) ([]byte, error)
+	VerifyReader(r io.Reader, signature []byte, key V) (bool, error)
+}
+
+// Detached signs and verifies JWS with detached payload,
+// the payload is not in the token and is given when verify.
+type Detached[S any, V any] struct {
+	signer  jwt.ISigner[S, V]
+	encoder jwt.IEncoder
+}
+
+func NewDetached[S any, V any](signer jwt.ISigner[S, V], encoder jwt.IEncoder) Detached[S, V] {
+	return Detached[S, V]{
+		signer:  signer,
+		encoder: encoder,
+	}
+}
+
+// return a clone Detached
+func (d Detached[S, V]) New() *Detached[S, V] {
+	return &Detached[S, V]{
+		signer:  d.signer,
+		encoder: d.encoder,
+	}
+}
+
+// Signer algo name.
+func (d *Detached[S, V]) Alg() string {
+	return d.signer.Alg()
+}
+
+// with new encoder
+func (d *Detached[S, V]) WithEncoder(encoder jwt.IEncoder) *Detached[S, V] {
+	d.encoder = encoder
+	return d
+}
+
+// Sign signs the base64url encoded payload and returns the
+// detached token "header..signature".
+func (d *Detached[S, V]) Sign(payload []byte, signKey S) (string, error) {
+	return d.SignReader(bytes.NewReader(payload), signKey)
+}
+
+// SignReader signs the base64url encoded payload read from r
+// and returns the detached token.
+func (d *Detached[S, V]) SignReader(r io.Reader, signKey S) (string, error) {
+	header := detachedHeader{
+		Alg: d.signer.Alg(),
+	}
+
+	return d.sign(header, r, signKey)
+}
+
+// SignUnencoded signs the unencoded payload with RFC 7797
+// "b64": false header and returns the detached token.
+func (d *Detached[S, V]) SignUnencoded(payload []byte, signKey S) (string, error) {
+	return d.SignUnencodedReader(bytes.NewReader(payload), signKey)
+}
+
+// SignUnencodedReader signs the unencoded payload read from r with
+// RFC 7797 "b64": false header and returns the detached token.
+// The payload is not buffered when the signer supports SignReader.
+func (d *Detached[S, V]) SignUnencodedReader(r io.Reader, signKey S) (string, error) {
+	b64 := false
+	header := detachedHeader{
+		Alg:  d.signer.Alg(),
+		B64:  &b64,
+		Crit: []string{"b64"},
+	}
+
+	return d.sign(header, r, signKey)
+}
+
+func (d *Detached[S, V]) sign(header detachedHeader, r io.Reader, signKey S) (string, error) {
+	encodedHeader, err := d.encoder.JSONEncode(header)
+	if err != nil {
+		return "", err
+	}
+
+	protected, err := d.encoder.Base64URLEncode(encodedHeader)
+	if err != nil {
+		return "", err
+	}
+
+	input, err := d.signingInput(protected, header, r)
+	if err != nil {
+		return "", err
+	}
+
+	var signed []byte
+	if signer, ok := d.signer.(readerSigner[S, V]); ok {
+		signed, err = signer.SignReader(input, signKey)
+	} else {
+		var msg []byte
+		if msg, err = io.ReadAll(input); err == nil {
+			signed, err = d.signer.Sign(msg, signKey)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := d.encoder.Base64URLEncode(signed)
+	if err != nil {
+		return "", err
+	}
+
+	return protected + ".." + signature, nil
+}
+
+// Verify verifies the detached token with the payload read from r.
+// The payload is not buffered when the signer supports VerifyReader.
+// A wrong signature is jwt.ErrJWTVerifyFail, the other errors of the
+// signer, as the compliance and the key errors, are returned.
+func (d *Detached[S, V]) Verify(tokenString string, r io.Reader, verifyKey V) (bool, error) {
+	protected, signature, header, err := d.parse(tokenString)
+	if err != nil {
+		return false, err
+	}
+
+	input, err := d.signingInput(protected, header, r)
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	if signer, isReader := d.signer.(readerSigner[S, V]); isReader {
+		ok, err = signer.VerifyReader(input, signature, verifyKey)
+	} else {
+		var msg []byte
+		if msg, err = io.ReadAll(input); err == nil {
+			ok, err = d.signer.Verify(msg, signature, verifyKey)
+		}
+	}
+
+	if ok {
+		return true, nil
+	}
+
+	if err == nil || isSignatureError(err) {
+		return false, jwt.ErrJWTVerifyFail
+	}
+
+	return false, err
+}
+
+// isSignatureError reports whether the error of the signer is of a wrong
+// signature.
+func isSignatureError(err error) bool {
+	switch err {
+	case ErrSignGmSM2VerifyFail, ErrSignGmSM2SignLengthInvalid,
+		ErrSignES256KVerifyFail, ErrSignES256KSignLengthInvalid,
+		ErrSignHSM3VerifyFail, jwt.ErrSignHmacVerifyFail,
+		ErrSM2SignatureEncodingInvalid:
+		return true
+	}
+
+	return false
+}
+
+func (d *Detached[S, V]) parse(tokenString string) (string, []byte, detachedHeader, error) {
+	var header detachedHeader
+
+	list := strings.Split(tokenString, ".")
+	if len(list) != 3 || list[1] != "" {
+		return "", nil, header, ErrDetachedTokenInvalid
+	}
+
+	decodedHeader, err := d.encoder.Base64URLDecode(list[0])
+	if err != nil {
+		return "", nil, header, err
+	}
+
+	if err := d.encoder.JSONDecode(decodedHeader, &header); err != nil {
+		return "", nil, header, err
+	}
+
+	if header.Alg != d.signer.Alg() {
+		return "", nil, header, jwt.ErrJWTAlgoInvalid
+	}
+
+	// b64 must be understood as critical, and only b64 is understood.
+	for _, crit := range header.Crit {
+		if crit != "b64" {
+			return "", nil, header, ErrDetachedCritInvalid
+		}
+	}
+	if header.B64 != nil && !containsString(header.Crit, "b64") {
+		return "", nil, header, ErrDetachedCritInvalid
+	}
+
+	signature, err := d.encoder.Base64URLDecode(list[2])
+	if err != nil {
+		return "", nil, header, err
+	}
+
+	return list[0], signature, header, nil
+}
+
+// signingInput returns the reader of "header.payload", the payload is
+// base64url encoded when b64 is not false.
+func (d *Detached[S, V]) signingInput(protected string, header detachedHeader, r io.Reader) (io.Reader, error) {
+	prefix := strings.NewReader(protected + ".")
+
+	if header.B64 != nil && !*header.B64 {
+		return io.MultiReader(prefix, r), nil
+	}
+
+	if _, ok := d.encoder.(*jwt.JoseEncoder); ok {
+		return io.MultiReader(prefix, newBase64URLReader(r)), nil
+	}
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := d.encoder.Base64URLEncode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.MultiReader(prefix, strings.NewReader(encoded)), nil
+}
+
+// base64URLReader encodes the data read from r with raw base64url.
+type base64URLReader struct {
+	r      io.Reader
+	in     [3 * 1024]byte
+	outBuf [4 * 1024]byte
+	out    []byte
+	eof    bool
+}
+
+func newBase64URLReader(r io.Reader) *base64URLReader {
+	return &base64URLReader{
+		r: r,
+	}
+}
+
+func (b *base64URLReader) Read(p []byte) (int, error) {
+	for len(b.out) == 0 {
+		if b.eof {
+			return 0, io.EOF
+		}
+
+		// read full blocks so only the last block has padding removed
+		n, err := io.ReadFull(b.r, b.in[:])
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			b.eof = true
+		} else if err != nil {
+			return 0, err
+		}
+
+		b.out = b.outBuf[:base64.RawURLEncoding.EncodedLen(n)]
+		base64.RawURLEncoding.Encode(b.out, b.in[:n])
+	}
+
+	n := copy(p, b.out)
+	b.out = b.out[n:]
+
+	return n, nil
+}
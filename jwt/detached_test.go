@@ -0,0 +1,325 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-cryptobin/hash/sm3"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func Test_DetachedGmSM2(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := &privateKey.PublicKey
+
+	payload := []byte("large document.with dots")
+
+	d := DetachedGmSM2.New()
+
+	for _, unencoded := range []bool{false, true} {
+		var tokenString string
+		if unencoded {
+			tokenString, err = d.SignUnencoded(payload, privateKey)
+		} else {
+			tokenString, err = d.Sign(payload, privateKey)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(tokenString, "..") {
+			t.Errorf("Sign got %s, want detached token", tokenString)
+		}
+
+		ok, err := d.Verify(tokenString, bytes.NewReader(payload), publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Error("Verify fail")
+		}
+
+		_, err = d.Verify(tokenString, strings.NewReader("other document"), publicKey)
+		if !errors.Is(err, jwt.ErrJWTVerifyFail) {
+			t.Errorf("Verify got %v, want %v", err, jwt.ErrJWTVerifyFail)
+		}
+	}
+}
+
+func Test_DetachedES256K(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(secp256k1.S256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := &privateKey.PublicKey
+
+	payload := []byte("large document")
+
+	d := DetachedES256K.New()
+
+	tokenString, err := d.SignUnencoded(payload, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := d.Verify(tokenString, bytes.NewReader(payload), publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("Verify fail")
+	}
+}
+
+func Test_DetachedHSM3(t *testing.T) {
+	key := []byte("test-key")
+	payload := []byte("$.02")
+
+	d := DetachedHSM3.New()
+
+	tokenString, err := d.SignUnencoded(payload, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// RFC 7797 protected header with b64 false
+	want := "eyJhbGciOiJIU00zIiwiYjY0IjpmYWxzZSwiY3JpdCI6WyJiNjQiXX0.."
+	if !strings.HasPrefix(tokenString, want) {
+		t.Errorf("SignUnencoded got %s, want prefix %s", tokenString, want)
+	}
+
+	ok, err := d.Verify(tokenString, bytes.NewReader(payload), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("Verify fail")
+	}
+
+	// the unencoded and encoded signing inputs are different
+	encoded, err := d.Sign(payload, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := strings.Split(encoded, ".")
+	list[0] = strings.Split(tokenString, ".")[0]
+	_, err = d.Verify(strings.Join(list, "."), bytes.NewReader(payload), key)
+	if !errors.Is(err, jwt.ErrJWTVerifyFail) {
+		t.Errorf("Verify got %v, want %v", err, jwt.ErrJWTVerifyFail)
+	}
+}
+
+func Test_DetachedHSM3_SignReader(t *testing.T) {
+	key := []byte("test-key")
+
+	d := DetachedHSM3.New()
+	if _, ok := d.signer.(readerSigner[[]byte, []byte]); !ok {
+		t.Fatal("the HSM3 signer does not hash the reader")
+	}
+
+	// 8 MiB of zero bytes, not buffered
+	size := int64(8 << 20)
+	payload := func() io.Reader {
+		return io.LimitReader(zeroReader{}, size)
+	}
+
+	tokenString, err := d.SignUnencodedReader(payload(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := d.Verify(tokenString, payload(), key)
+	if err != nil || !ok {
+		t.Fatalf("Verify got %v, %v", ok, err)
+	}
+
+	// the signature is HMAC-SM3 of "header." || payload
+	mac := hmac.New(sm3.New, key)
+	mac.Write([]byte(strings.Split(tokenString, ".")[0] + "."))
+	io.Copy(mac, payload())
+
+	signature, err := base64.RawURLEncoding.DecodeString(strings.Split(tokenString, ".")[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		t.Error("the signature is not HMAC-SM3 of the signing input")
+	}
+
+	_, err = d.Verify(tokenString, io.LimitReader(zeroReader{}, size-1), key)
+	if !errors.Is(err, jwt.ErrJWTVerifyFail) {
+		t.Errorf("Verify got %v, want %v", err, jwt.ErrJWTVerifyFail)
+	}
+}
+
+func Test_Detached_VerifyErrors(t *testing.T) {
+	key := []byte("test-key")
+	payload := []byte("document")
+
+	tokenString, err := DetachedHSM3.New().Sign(payload, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	EnableCompliance(NewComplianceProfile("SM2", "GmSM2"))
+	_, err = DetachedHSM3.New().Verify(tokenString, bytes.NewReader(payload), key)
+	DisableCompliance()
+
+	if err != ErrComplianceAlgNotPermitted {
+		t.Errorf("Verify got %v, want %v", err, ErrComplianceAlgNotPermitted)
+	}
+
+	// the key of other curve
+	privateKey, err := ecdsa.GenerateKey(secp256k1.S256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenString, err = DetachedES256K.New().Sign(payload, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = DetachedES256K.New().Verify(tokenString, bytes.NewReader(payload), &otherKey.PublicKey)
+	if err != ErrSignES256KKeyInvalid {
+		t.Errorf("Verify got %v, want %v", err, ErrSignES256KKeyInvalid)
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+
+	return len(p), nil
+}
+
+func Test_Detached_Invalid(t *testing.T) {
+	key := []byte("test-key")
+	payload := []byte("payload")
+
+	d := DetachedHSM3.New()
+
+	tokenString, err := SigningMethodHSM3.New().Sign(map[string]string{"foo": "bar"}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = d.Verify(tokenString, bytes.NewReader(payload), key)
+	if !errors.Is(err, ErrDetachedTokenInvalid) {
+		t.Errorf("Verify got %v, want %v", err, ErrDetachedTokenInvalid)
+	}
+
+	// {"alg":"HSM3","b64":false}
+	noCrit := "eyJhbGciOiJIU00zIiwiYjY0IjpmYWxzZX0..AA"
+	_, err = d.Verify(noCrit, bytes.NewReader(payload), key)
+	if !errors.Is(err, ErrDetachedCritInvalid) {
+		t.Errorf("Verify got %v, want %v", err, ErrDetachedCritInvalid)
+	}
+
+	// {"alg":"HSM3","crit":["exp"]}
+	unknownCrit := "eyJhbGciOiJIU00zIiwiY3JpdCI6WyJleHAiXX0..AA"
+	_, err = d.Verify(unknownCrit, bytes.NewReader(payload), key)
+	if !errors.Is(err, ErrDetachedCritInvalid) {
+		t.Errorf("Verify got %v, want %v", err, ErrDetachedCritInvalid)
+	}
+
+	// {"alg":"GmSM2"}
+	otherAlg := "eyJhbGciOiJHbVNNMiJ9..AA"
+	_, err = d.Verify(otherAlg, bytes.NewReader(payload), key)
+	if !errors.Is(err, jwt.ErrJWTAlgoInvalid) {
+		t.Errorf("Verify got %v, want %v", err, jwt.ErrJWTAlgoInvalid)
+	}
+}
+
+func Test_DetachedGmSM2_SignReader(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := &privateKey.PublicKey
+
+	payload := bytes.Repeat([]byte("0123456789"), 100001)
+
+	d := DetachedGmSM2.New()
+
+	tokenString, err := d.SignReader(bytes.NewReader(payload), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the streamed signature verifies with the buffered signing input
+	protected := strings.Split(tokenString, ".")[0]
+	signature, _ := jwt.JWTEncoder.Base64URLDecode(strings.Split(tokenString, ".")[2])
+	encoded, _ := jwt.JWTEncoder.Base64URLEncode(payload)
+
+	ok, err := SigningGmSM2.Verify([]byte(protected+"."+encoded), signature, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("Verify fail")
+	}
+
+	ok, err = d.Verify(tokenString, bytes.NewReader(payload), publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("Verify fail")
+	}
+
+	tokenString, err = d.SignUnencodedReader(bytes.NewReader(payload), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err = d.Verify(tokenString, bytes.NewReader(payload), publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("Verify fail")
+	}
+}
+
+func Test_base64URLReader(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 3071, 3072, 3073, 10000} {
+		data := bytes.Repeat([]byte{0xfb, 0xff, 0x01}, n)[:n]
+
+		got, err := io.ReadAll(newBase64URLReader(bytes.NewReader(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := base64.RawURLEncoding.EncodeToString(data)
+		if string(got) != want {
+			t.Errorf("base64URLReader %d got %s, want %s", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+// fuzz keys are the keys of testdata, the external tokens of the keys
+// are accepted by the Parse targets.
+func fuzzSM2PrivateKey(f *testing.F) *sm2.PrivateKey {
+	privateKey, err := ParseSM2PrivateKeyFromDer(fuzzKeyFixture(f, "sm2_private.pem"))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	return privateKey
+}
+
+func fuzzES256KPrivateKey(f *testing.F) *ecdsa.PrivateKey {
+	privateKey, err := ParseECPrivateKeyFromDer(fuzzKeyFixture(f, "secp256k1_private.pem"))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	return privateKey
+}
+
+func fuzzTokenFixtures(f *testing.F, alg string) []string {
+	data, err := os.ReadFile(filepath.Join("testdata", "tokens.json"))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	var fixtures []tokenFixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		f.Fatal(err)
+	}
+
+	var tokens []string
+	for _, fx := range fixtures {
+		if fx.Alg == alg {
+			tokens = append(tokens, fx.Token)
+		}
+	}
+
+	return tokens
+}
+
+func fuzzKeyFixture(f *testing.F, name string) []byte {
+	pemBytes, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	der, err := jwt.ParsePEM(pemBytes)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	return der
+}
+
+// fuzzSigningInput returns the first two segments of the token, the go-jwt
+// parser reads the first three segments only.
+func fuzzSigningInput(tokenString string) string {
+	parts := strings.SplitN(tokenString, ".", 3)
+	if len(parts) < 2 {
+		return tokenString
+	}
+
+	return parts[0] + "." + parts[1]
+}
+
+func Fuzz_SigningGmSM2_Verify(f *testing.F) {
+	privateKey := fuzzSM2PrivateKey(f)
+	publicKey := &privateKey.PublicKey
+
+	msg := []byte("test-data")
+	signed, err := SigningGmSM2.Sign(msg, privateKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(msg, signed)
+	f.Add(msg, signed[:32])
+	f.Add([]byte{}, make([]byte, 64))
+	f.Add(msg, bytes.Repeat([]byte{0xff}, 64))
+
+	f.Fuzz(func(t *testing.T, data []byte, signature []byte) {
+		veri, err := SigningGmSM2.Verify(data, signature, publicKey)
+		if veri != (err == nil) {
+			t.Fatalf("Verify got %v with error %v", veri, err)
+		}
+
+		if veri && !bytes.Equal(data, msg) {
+			t.Fatalf("Verify accepted %x for %q", signature, data)
+		}
+	})
+}
+
+func Fuzz_SigningES256K_Verify(f *testing.F) {
+	privateKey := fuzzES256KPrivateKey(f)
+	publicKey := &privateKey.PublicKey
+
+	msg := []byte("test-data")
+	signed, err := SigningES256K.Sign(msg, privateKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(msg, signed)
+	f.Add(msg, signed[:32])
+	f.Add([]byte{}, make([]byte, 64))
+	f.Add(msg, bytes.Repeat([]byte{0xff}, 64))
+
+	f.Fuzz(func(t *testing.T, data []byte, signature []byte) {
+		veri, err := SigningES256K.Verify(data, signature, publicKey)
+		if veri != (err == nil) {
+			t.Fatalf("Verify got %v with error %v", veri, err)
+		}
+
+		if veri && !bytes.Equal(data, msg) {
+			t.Fatalf("Verify accepted %x for %q", signature, data)
+		}
+	})
+}
+
+func Fuzz_ParseSM2PublicKeyFromDer(f *testing.F) {
+	der := fuzzKeyFixture(f, "sm2_public.pem")
+
+	f.Add(der)
+	f.Add(der[:len(der)-1])
+	f.Add(fuzzKeyFixture(f, "secp256k1_public.pem"))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		publicKey, err := ParseSM2PublicKeyFromDer(data)
+		if err != nil {
+			return
+		}
+
+		if publicKey == nil || publicKey.X == nil || publicKey.Y == nil {
+			t.Fatalf("ParseSM2PublicKeyFromDer got %v", publicKey)
+		}
+	})
+}
+
+func Fuzz_ParseECPublicKeyFromDer(f *testing.F) {
+	der := fuzzKeyFixture(f, "secp256k1_public.pem")
+
+	f.Add(der)
+	f.Add(der[:len(der)-1])
+	f.Add(fuzzKeyFixture(f, "sm2_public.pem"))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		publicKey, err := ParseECPublicKeyFromDer(data)
+		if err != nil {
+			return
+		}
+
+		if publicKey == nil || publicKey.X == nil || publicKey.Y == nil {
+			t.Fatalf("ParseECPublicKeyFromDer got %v", publicKey)
+		}
+	})
+}
+
+// fuzzParse checks ParseStrict accepts only the three segments tokens
+// signed with the signing input of a seed token, and the go-jwt parser
+// does not panic. The go-jwt parser verifies the tokens with more segments
+// or with the base64 errors, ParseStrict refuses them.
+func fuzzParse[S any, V any](f *testing.F, method jwt.JWT[S, V], signKey S, verifyKeys []V, tokens []string) {
+	tokenString, err := method.New().Sign(map[string]string{"foo": "bar"}, signKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	tokens = append(tokens, tokenString)
+
+	signed := make(map[string]bool)
+	for _, token := range tokens {
+		signed[fuzzSigningInput(token)] = true
+
+		f.Add(token)
+		f.Add(token[:len(token)-1])
+		f.Add(token + ".x")
+		f.Add(fuzzSigningInput(token))
+	}
+
+	f.Add("")
+	f.Add("..")
+	f.Add("e30.e30.")
+
+	f.Fuzz(func(t *testing.T, data string) {
+		for _, verifyKey := range verifyKeys {
+			method.New().Parse(data, verifyKey)
+
+			parsed, err := ParseStrict(method, data, verifyKey)
+			if err != nil {
+				continue
+			}
+
+			if parsed == nil {
+				t.Fatal("ParseStrict got nil token")
+			}
+
+			if CheckTokenSegments(data) != nil || !signed[fuzzSigningInput(data)] {
+				t.Fatalf("ParseStrict accepted %q", data)
+			}
+		}
+	})
+}
+
+func Fuzz_SigningMethodGmSM2_Parse(f *testing.F) {
+	privateKey := fuzzSM2PrivateKey(f)
+
+	fuzzParse(f, SigningMethodGmSM2, privateKey, []*sm2.PublicKey{&privateKey.PublicKey}, fuzzTokenFixtures(f, "GmSM2"))
+}
+
+func Fuzz_SigningMethodES256K_Parse(f *testing.F) {
+	privateKey := fuzzES256KPrivateKey(f)
+
+	block, _ := pem.Decode([]byte(strings.TrimSpace(es256kCheckPublicKey)))
+	checkKey, err := ParseECPublicKeyFromDer(block.Bytes)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	tokens := append(fuzzTokenFixtures(f, "ES256K"), es256kCheckToken)
+
+	fuzzParse(f, SigningMethodES256K, privateKey, []*ecdsa.PublicKey{&privateKey.PublicKey, checkKey}, tokens)
+}
+
+func Fuzz_SigningMethodHSM3_Parse(f *testing.F) {
+	key := []byte("test-key")
+
+	fuzzParse(f, SigningMethodHSM3, key, [][]byte{key}, fuzzTokenFixtures(f, "HSM3"))
+}
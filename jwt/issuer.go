@@ -0,0 +1,345 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var ErrIssuerKeyInvalid = errors.New("go-jwt: Issuer key invalid")
+
+// jwksMaxAge is the max age the verifiers cache the JWKS, the next key
+// is published at least the max age before it signs.
+const jwksMaxAge = 5 * time.Minute
+
+type issuerKey struct {
+	kid     string
+	key     *sm2.PrivateKey
+	created time.Time
+
+	// retired is the time of the rotation, zero for the current key
+	retired time.Time
+}
+
+// Issuer issues GmSM2 tokens with the iss, iat, exp, jti claims and the
+// kid header. The signing key is rotated on the schedule when a token is
+// issued. The next key is published in the JWKS the JWKS max age before
+// the rotation, and the retired keys for the overlap period, so the
+// verifiers with a cached JWKS know the keys of the tokens.
+// It is safe for concurrent use.
+type Issuer struct {
+	mu       sync.Mutex
+	name     string
+	ttl      time.Duration
+	rotation time.Duration
+	overlap  time.Duration
+	keys     []*issuerKey
+
+	// next is the published key signing after the rotation, nil when
+	// it is not generated yet
+	next *issuerKey
+
+	// now is the clock of the rotation and the claims.
+	now func() time.Time
+}
+
+// NewIssuer returns the Issuer of the iss name, the tokens expire after
+// the ttl. The key is generated on the first token and never rotated
+// unless WithRotation is set.
+func NewIssuer(name string, ttl time.Duration) *Issuer {
+	return &Issuer{
+		name:    name,
+		ttl:     ttl,
+		overlap: ttl,
+		now:     time.Now,
+	}
+}
+
+// WithRotation rotates the key every the interval, the retired keys are
+// published for the overlap, an overlap less than the ttl is the ttl.
+func (i *Issuer) WithRotation(interval time.Duration, overlap time.Duration) *Issuer {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if overlap < i.ttl {
+		overlap = i.ttl
+	}
+
+	i.rotation = interval
+	i.overlap = overlap
+
+	return i
+}
+
+// AddKey adds the key as the current signing key, the kid is the JWK
+// thumbprint when empty. The key is used to restore the issuer.
+func (i *Issuer) AddKey(kid string, key *sm2.PrivateKey) error {
+	if key == nil || key.Curve != sm2.P256() {
+		return ErrIssuerKeyInvalid
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.addKey(kid, key, i.now())
+
+	return nil
+}
+
+// Rotate uses the next key, or a new key, as the signing key at once and
+// retires the current key. The verifiers may not know a new key until
+// their cached JWKS expires.
+func (i *Issuer) Rotate() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.rotate(i.now())
+}
+
+// Issue signs the claims. The iss, iat, exp and jti claims are set by
+// the issuer.
+func (i *Issuer) Issue(claims map[string]any) (string, error) {
+	var jti [16]byte
+	if _, err := rand.Read(jti[:]); err != nil {
+		return "", err
+	}
+
+	i.mu.Lock()
+	now := i.now()
+
+	key, err := i.currentKey(now)
+	i.mu.Unlock()
+
+	if err != nil {
+		return "", err
+	}
+
+	tokenClaims := make(map[string]any, len(claims)+4)
+	for k, v := range claims {
+		tokenClaims[k] = v
+	}
+
+	tokenClaims["iss"] = i.name
+	tokenClaims["iat"] = now.Unix()
+	tokenClaims["exp"] = now.Add(i.ttl).Unix()
+	tokenClaims["jti"] = hex.EncodeToString(jti[:])
+
+	header := jwt.TokenHeader{
+		Typ: "JWT",
+		Alg: SigningGmSM2.Alg(),
+		Kid: key.kid,
+	}
+
+	return SigningMethodGmSM2.New().SignWithHeader(header, tokenClaims, key.key)
+}
+
+// PublicKey returns the published public key of the kid.
+func (i *Issuer) PublicKey(kid string) (*sm2.PublicKey, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := i.now()
+	i.prune(now)
+
+	if err := i.prepareNext(now); err != nil {
+		return nil, false
+	}
+
+	for _, k := range i.publishedKeys() {
+		if k.kid == kid {
+			return &k.key.PublicKey, true
+		}
+	}
+
+	return nil, false
+}
+
+// Parse verifies the token with the published key of the kid header,
+// and validates the exp, nbf and iat claims and the iss of the issuer.
+func (i *Issuer) Parse(tokenString string) (*jwt.Token, error) {
+	if err := CheckTokenSegments(tokenString); err != nil {
+		return nil, err
+	}
+
+	header, err := jwt.GetTokenHeader(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := i.PublicKey(header.Kid)
+	if !ok {
+		return nil, ErrJWKNotFound
+	}
+
+	token, err := SigningMethodGmSM2.New().Parse(tokenString, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateClaims(token, i.now(), 0, "", i.name); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// JWKS returns the JWKS of the current, the overlapped and the next keys.
+func (i *Issuer) JWKS() *JWKSet {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := i.now()
+	i.prune(now)
+
+	// the JWKS without the next key is still valid
+	i.prepareNext(now)
+
+	keys := i.publishedKeys()
+
+	set := &JWKSet{
+		Keys: make([]JWK, 0, len(keys)),
+	}
+
+	for _, k := range keys {
+		set.Keys = append(set.Keys, NewSM2JWK(k.kid, &k.key.PublicKey))
+	}
+
+	return set
+}
+
+// JWKSHandler returns the handler serving the JWKS document.
+func (i *Issuer) JWKSHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := json.Marshal(i.JWKS())
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/jwk-set+json")
+		w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(int(jwksMaxAge/time.Second)))
+		w.Write(data)
+	})
+}
+
+// currentKey returns the signing key, and rotates the key on the schedule
+// when the next key has been published for the JWKS max age.
+func (i *Issuer) currentKey(now time.Time) (*issuerKey, error) {
+	if len(i.keys) == 0 {
+		if err := i.rotate(now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := i.prepareNext(now); err != nil {
+		return nil, err
+	}
+
+	current := i.keys[len(i.keys)-1]
+	if i.next != nil &&
+		!now.Before(current.created.Add(i.rotation)) &&
+		!now.Before(i.next.created.Add(jwksMaxAge)) {
+		if err := i.rotate(now); err != nil {
+			return nil, err
+		}
+	}
+
+	return i.keys[len(i.keys)-1], nil
+}
+
+// prepareNext generates the next key the JWKS max age before the rotation.
+func (i *Issuer) prepareNext(now time.Time) error {
+	if i.rotation <= 0 || i.next != nil || len(i.keys) == 0 {
+		return nil
+	}
+
+	current := i.keys[len(i.keys)-1]
+	if now.Before(current.created.Add(i.rotation - jwksMaxAge)) {
+		return nil
+	}
+
+	key, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	i.next = &issuerKey{
+		kid:     NewSM2JWK("", &key.PublicKey).Thumbprint(),
+		key:     key,
+		created: now,
+	}
+
+	return nil
+}
+
+// rotate uses the next key, or a new key, as the signing key.
+func (i *Issuer) rotate(now time.Time) error {
+	if next := i.next; next != nil {
+		i.next = nil
+		i.addKey(next.kid, next.key, now)
+
+		return nil
+	}
+
+	key, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	i.addKey("", key, now)
+
+	return nil
+}
+
+// publishedKeys returns the current key first, the retired keys and the
+// next key.
+func (i *Issuer) publishedKeys() []*issuerKey {
+	keys := make([]*issuerKey, 0, len(i.keys)+1)
+	for n := len(i.keys) - 1; n >= 0; n-- {
+		keys = append(keys, i.keys[n])
+	}
+
+	if i.next != nil {
+		keys = append(keys, i.next)
+	}
+
+	return keys
+}
+
+func (i *Issuer) addKey(kid string, key *sm2.PrivateKey, now time.Time) {
+	if kid == "" {
+		kid = NewSM2JWK("", &key.PublicKey).Thumbprint()
+	}
+
+	if len(i.keys) > 0 {
+		i.keys[len(i.keys)-1].retired = now
+	}
+
+	i.keys = append(i.keys, &issuerKey{
+		kid:     kid,
+		key:     key,
+		created: now,
+	})
+
+	i.prune(now)
+}
+
+// prune removes the retired keys after the overlap.
+func (i *Issuer) prune(now time.Time) {
+	keys := i.keys[:0]
+	for _, k := range i.keys {
+		if k.retired.IsZero() || now.Before(k.retired.Add(i.overlap)) {
+			keys = append(keys, k)
+		}
+	}
+
+	i.keys = keys
+}
@@ -0,0 +1,256 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func Test_Issuer(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	issuer := NewIssuer("https://issuer.example.com", 10*time.Minute)
+	issuer.now = func() time.Time {
+		return now
+	}
+
+	tokenString, err := issuer.Issue(map[string]any{
+		"sub": "foo",
+		"iss": "ignored",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := issuer.Parse(tokenString)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, err := parsed.GetHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := issuer.JWKS()
+	if len(set.Keys) != 1 || header.Kid != set.Keys[0].Kid || header.Kid != set.Keys[0].Thumbprint() {
+		t.Errorf("kid got %s, JWKS %+v", header.Kid, set)
+	}
+
+	var claims jwt.RegisteredClaims
+	if err := parsed.GetClaimsT(&claims); err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.Issuer != "https://issuer.example.com" || claims.Subject != "foo" || claims.ID == "" {
+		t.Errorf("claims got %+v", claims)
+	}
+	if claims.IssuedAt.Unix() != now.Unix() || claims.ExpiresAt.Unix() != now.Add(10*time.Minute).Unix() {
+		t.Errorf("claims iat %v exp %v", claims.IssuedAt, claims.ExpiresAt)
+	}
+
+	// the verifiers use the JWKS
+	publicKey, err := set.PublicKey(header.Kid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SigningMethodGmSM2.New().Parse(tokenString, publicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// without rotation the key is not changed
+	now = now.Add(24 * time.Hour)
+
+	tokenString2, err := issuer.Issue(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header2, err := jwt.GetTokenHeader(tokenString2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header2.Kid != header.Kid {
+		t.Errorf("kid got %s, want %s", header2.Kid, header.Kid)
+	}
+}
+
+func Test_Issuer_Rotation(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	issuer := NewIssuer("issuer", 10*time.Minute).
+		WithRotation(time.Hour, 30*time.Minute)
+	issuer.now = func() time.Time {
+		return now
+	}
+
+	kid := func(tokenString string) string {
+		header, err := jwt.GetTokenHeader(tokenString)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return header.Kid
+	}
+
+	issue := func() string {
+		tokenString, err := issuer.Issue(map[string]any{"sub": "foo"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return tokenString
+	}
+
+	token1 := issue()
+
+	// the next key is published the JWKS max age before the rotation
+	now = now.Add(55 * time.Minute)
+
+	set := issuer.JWKS()
+	if len(set.Keys) != 2 || set.Keys[0].Kid != kid(token1) {
+		t.Fatalf("JWKS got %+v", set)
+	}
+
+	nextKid := set.Keys[1].Kid
+
+	now = now.Add(4 * time.Minute)
+	token2 := issue()
+
+	if kid(token1) != kid(token2) {
+		t.Error("key rotated before the interval")
+	}
+
+	// rotated to the published key
+	now = now.Add(time.Minute)
+	token3 := issue()
+
+	if kid(token3) != nextKid {
+		t.Errorf("kid got %s, want %s", kid(token3), nextKid)
+	}
+
+	set = issuer.JWKS()
+	if len(set.Keys) != 2 || set.Keys[0].Kid != kid(token3) || set.Keys[1].Kid != kid(token1) {
+		t.Errorf("JWKS got %+v", set)
+	}
+
+	// the retired key verifies in the overlap
+	now = now.Add(5 * time.Minute)
+	if _, err := issuer.Parse(token2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the expired token is refused while its key is published
+	now = now.Add(24 * time.Minute)
+	if _, err := issuer.Parse(token2); err != ErrTokenExpired {
+		t.Errorf("Parse got %v, want %v", err, ErrTokenExpired)
+	}
+
+	if set := issuer.JWKS(); len(set.Keys) != 2 || set.Keys[1].Kid != kid(token2) {
+		t.Errorf("JWKS got %+v", set)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := issuer.Parse(token2); err != ErrJWKNotFound {
+		t.Errorf("Parse got %v, want %v", err, ErrJWKNotFound)
+	}
+
+	if _, err := issuer.Parse(issue()); err != nil {
+		t.Fatal(err)
+	}
+
+	if set := issuer.JWKS(); len(set.Keys) != 1 {
+		t.Errorf("JWKS got %d keys, want 1", len(set.Keys))
+	}
+
+	// the next key not published before the rotation time signs after
+	// the JWKS max age
+	now = now.Add(2 * time.Hour)
+	token4 := issue()
+
+	if kid(token4) != kid(token3) {
+		t.Error("key rotated before the next key is published for the JWKS max age")
+	}
+
+	set = issuer.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS got %+v", set)
+	}
+
+	now = now.Add(jwksMaxAge)
+	token5 := issue()
+
+	if kid(token5) != set.Keys[1].Kid {
+		t.Errorf("kid got %s, want %s", kid(token5), set.Keys[1].Kid)
+	}
+}
+
+func Test_Issuer_AddKey(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := NewIssuer("issuer", time.Minute)
+	if err := issuer.AddKey("k1", privateKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := issuer.AddKey("k2", nil); err != ErrIssuerKeyInvalid {
+		t.Errorf("AddKey got %v, want %v", err, ErrIssuerKeyInvalid)
+	}
+
+	tokenString, err := issuer.Issue(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SigningMethodGmSM2.New().Parse(tokenString, &privateKey.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// the token of the key with another iss is refused
+	otherToken, err := SigningMethodGmSM2.New().SignWithHeader(jwt.TokenHeader{Typ: "JWT", Alg: "GmSM2", Kid: "k1"}, map[string]any{"iss": "other"}, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := issuer.Parse(otherToken); err != ErrIssuerInvalid {
+		t.Errorf("Parse got %v, want %v", err, ErrIssuerInvalid)
+	}
+
+	if err := issuer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := issuer.PublicKey("k1"); !ok {
+		t.Error("the rotated key is not published")
+	}
+
+	w := httptest.NewRecorder()
+	issuer.JWKSHandler().ServeHTTP(w, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/jwk-set+json" {
+		t.Errorf("Content-Type got %s", ct)
+	}
+
+	if cc := w.Header().Get("Cache-Control"); cc != "max-age=300" {
+		t.Errorf("Cache-Control got %s", cc)
+	}
+
+	var set JWKSet
+	if err := json.Unmarshal(w.Body.Bytes(), &set); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(set.Keys) != 2 || set.Keys[1].Kid != "k1" {
+		t.Errorf("JWKS got %+v", set)
+	}
+}
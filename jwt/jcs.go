@@ -0,0 +1,333 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var (
+	ErrJCSNotCanonical  = errors.New("go-jwt: JSON is not canonical")
+	ErrJCSDuplicateKey  = errors.New("go-jwt: JSON has duplicate key")
+	ErrJCSNumberInvalid = errors.New("go-jwt: JSON number invalid")
+
+	ErrCanonicalTokenInvalid = errors.New("go-jwt: canonical token invalid")
+)
+
+var (
+	// canonical encoder, the header and the claims are RFC 8785 JSON.
+	CanonicalEncoder = NewJCSEncoder()
+
+	// canonical encoder refusing the JSON not canonical when decoding.
+	CanonicalStrictEncoder = NewJCSEncoder().WithStrict(true)
+)
+
+// JCSEncoder encodes the JSON with the RFC 8785 JSON Canonicalization
+// Scheme, the tokens are the same bytes of other JCS implementations.
+// The numbers are IEEE 754 doubles as JCS, the integers over 2^53 lose
+// precision.
+type JCSEncoder struct {
+	strict bool
+}
+
+func NewJCSEncoder() *JCSEncoder {
+	return &JCSEncoder{}
+}
+
+// WithStrict returns a copy of the encoder, the strict encoder refuses
+// the JSON not canonical when decoding.
+func (j *JCSEncoder) WithStrict(strict bool) *JCSEncoder {
+	return &JCSEncoder{
+		strict: strict,
+	}
+}
+
+func (j *JCSEncoder) Base64URLEncode(data []byte) (string, error) {
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func (j *JCSEncoder) Base64URLDecode(data string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(data)
+}
+
+func (j *JCSEncoder) JSONEncode(data any) ([]byte, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return CanonicalizeJSON(encoded)
+}
+
+func (j *JCSEncoder) JSONDecode(data []byte, dst any) error {
+	if j.strict && !IsCanonicalJSON(data) {
+		return ErrJCSNotCanonical
+	}
+
+	return json.Unmarshal(data, dst)
+}
+
+// ParseCanonical checks the header and the claims of the token are
+// canonical JSON, and parses the token with the method.
+func ParseCanonical[S any, V any](method jwt.JWT[S, V], tokenString string, verifyKey V) (*jwt.Token, error) {
+	if err := CheckCanonicalToken(tokenString); err != nil {
+		return nil, err
+	}
+
+	return method.New().WithEncoder(CanonicalStrictEncoder).Parse(tokenString, verifyKey)
+}
+
+// CheckCanonicalToken checks the header and the claims of the token are
+// canonical JSON. The token not of three base64url segments is
+// ErrCanonicalTokenInvalid.
+func CheckCanonicalToken(tokenString string) error {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return ErrCanonicalTokenInvalid
+	}
+
+	for _, part := range parts[:2] {
+		data, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return ErrCanonicalTokenInvalid
+		}
+
+		if !IsCanonicalJSON(data) {
+			return ErrJCSNotCanonical
+		}
+	}
+
+	return nil
+}
+
+// IsCanonicalJSON reports whether the data is RFC 8785 canonical JSON.
+func IsCanonicalJSON(data []byte) bool {
+	canonical, err := CanonicalizeJSON(data)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(canonical, data)
+}
+
+// CanonicalizeJSON returns the RFC 8785 canonical JSON of the data.
+func CanonicalizeJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, dec); err != nil {
+		return nil, err
+	}
+
+	// only one value
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, ErrJCSNotCanonical
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeCanonical(buf *bytes.Buffer, dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			return writeCanonicalObject(buf, dec)
+		case '[':
+			return writeCanonicalArray(buf, dec)
+		}
+
+		return ErrJCSNotCanonical
+	case string:
+		writeCanonicalString(buf, v)
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return ErrJCSNumberInvalid
+		}
+
+		s, err := formatCanonicalNumber(f)
+		if err != nil {
+			return err
+		}
+
+		buf.WriteString(s)
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	}
+
+	return nil
+}
+
+func writeCanonicalObject(buf *bytes.Buffer, dec *json.Decoder) error {
+	type member struct {
+		key   string
+		utf16 []uint16
+		value []byte
+	}
+
+	var members []member
+	seen := make(map[string]bool)
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			return ErrJCSNotCanonical
+		}
+
+		if seen[key] {
+			return ErrJCSDuplicateKey
+		}
+		seen[key] = true
+
+		var value bytes.Buffer
+		if err := writeCanonical(&value, dec); err != nil {
+			return err
+		}
+
+		members = append(members, member{
+			key:   key,
+			utf16: utf16.Encode([]rune(key)),
+			value: value.Bytes(),
+		})
+	}
+
+	// the closing '}'
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	// the keys are sorted by the UTF-16 code units
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i].utf16, members[j].utf16
+		for n := 0; n < len(a) && n < len(b); n++ {
+			if a[n] != b[n] {
+				return a[n] < b[n]
+			}
+		}
+
+		return len(a) < len(b)
+	})
+
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		writeCanonicalString(buf, m.key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+
+	return nil
+}
+
+func writeCanonicalArray(buf *bytes.Buffer, dec *json.Decoder) error {
+	buf.WriteByte('[')
+
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		if err := writeCanonical(buf, dec); err != nil {
+			return err
+		}
+	}
+
+	// the closing ']'
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	buf.WriteByte(']')
+
+	return nil
+}
+
+// writeCanonicalString writes the string as ECMAScript JSON.stringify.
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[r>>4])
+				buf.WriteByte(hex[r&0xf])
+				continue
+			}
+
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// formatCanonicalNumber formats the number as ECMAScript Number.toString.
+func formatCanonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", ErrJCSNumberInvalid
+	}
+
+	// -0 is 0
+	if f == 0 {
+		return "0", nil
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+
+	s := strconv.FormatFloat(f, format, -1, 64)
+
+	// the exponent has no leading zero, 1e-07 is 1e-7
+	if format == 'e' {
+		if n := strings.IndexByte(s, 'e'); n > 0 && len(s) > n+3 && s[n+2] == '0' {
+			s = s[:n+2] + s[n+3:]
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,116 @@
+package jwt
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+)
+
+var (
+	ErrJWKInvalid  = errors.New("go-jwt: JWK invalid")
+	ErrJWKNotFound = errors.New("go-jwt: JWK not found")
+)
+
+// JWK is the JSON Web Key of a SM2 public key,
+// the kty is "EC" and the crv is "SM2".
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKSet is the JWKS document.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewSM2JWK returns the JWK of the SM2 public key.
+func NewSM2JWK(kid string, key *sm2.PublicKey) JWK {
+	size := (key.Curve.Params().BitSize + 7) / 8
+
+	return JWK{
+		Kty: "EC",
+		Crv: "SM2",
+		Kid: kid,
+		Alg: SigningGmSM2.Alg(),
+		Use: "sig",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+// PublicKey returns the SM2 public key of the JWK.
+func (k JWK) PublicKey() (*sm2.PublicKey, error) {
+	if k.Kty != "EC" || k.Crv != "SM2" {
+		return nil, ErrJWKInvalid
+	}
+
+	curve := sm2.P256()
+	size := (curve.Params().BitSize + 7) / 8
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil || len(x) != size {
+		return nil, ErrJWKInvalid
+	}
+
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil || len(y) != size {
+		return nil, ErrJWKInvalid
+	}
+
+	key := &sm2.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+
+	if ValidateSM2PublicKey(key) != nil {
+		return nil, ErrJWKInvalid
+	}
+
+	return key, nil
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint of the JWK.
+func (k JWK) Thumbprint() string {
+	// the required members in lexicographic order
+	data, _ := json.Marshal(struct {
+		Crv string `json:"crv"`
+		Kty string `json:"kty"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}{k.Crv, k.Kty, k.X, k.Y})
+
+	sum := sha256.Sum256(data)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ParseJWKSet parses the JWKS document.
+func ParseJWKSet(data []byte) (*JWKSet, error) {
+	var set JWKSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	return &set, nil
+}
+
+// PublicKey returns the SM2 public key of the kid.
+func (s *JWKSet) PublicKey(kid string) (*sm2.PublicKey, error) {
+	for _, k := range s.Keys {
+		if k.Kid == kid {
+			return k.PublicKey()
+		}
+	}
+
+	return nil, ErrJWKNotFound
+}
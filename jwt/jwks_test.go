@@ -0,0 +1,86 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+)
+
+func Test_SM2JWK(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwk := NewSM2JWK("k1", &privateKey.PublicKey)
+	if jwk.Kty != "EC" || jwk.Crv != "SM2" || jwk.Alg != "GmSM2" || jwk.Kid != "k1" {
+		t.Errorf("NewSM2JWK got %+v", jwk)
+	}
+
+	data, err := json.Marshal(JWKSet{Keys: []JWK{jwk}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := ParseJWKSet(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := set.PublicKey("k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !publicKey.Equal(&privateKey.PublicKey) {
+		t.Error("PublicKey not equal")
+	}
+
+	if _, err := set.PublicKey("k2"); err != ErrJWKNotFound {
+		t.Errorf("PublicKey got %v, want %v", err, ErrJWKNotFound)
+	}
+
+	// the point is not on the curve
+	jwk.Y = jwk.X
+	if _, err := jwk.PublicKey(); err != ErrJWKInvalid {
+		t.Errorf("PublicKey got %v, want %v", err, ErrJWKInvalid)
+	}
+
+	// the point at infinity
+	zero := base64.RawURLEncoding.EncodeToString(make([]byte, 32))
+	identity := JWK{Kty: "EC", Crv: "SM2", X: zero, Y: zero}
+	if _, err := identity.PublicKey(); err != ErrJWKInvalid {
+		t.Errorf("PublicKey got %v, want %v", err, ErrJWKInvalid)
+	}
+
+	jwk.Crv = "P-256"
+	if _, err := jwk.PublicKey(); err != ErrJWKInvalid {
+		t.Errorf("PublicKey got %v, want %v", err, ErrJWKInvalid)
+	}
+}
+
+func Test_JWK_Thumbprint(t *testing.T) {
+	// the thumbprint only has the required members
+	jwk := JWK{
+		Kty: "EC",
+		Crv: "SM2",
+		X:   "x",
+		Y:   "y",
+	}
+
+	// base64url(SHA-256(`{"crv":"SM2","kty":"EC","x":"x","y":"y"}`))
+	want := "nMJNabv7HHHWWuRTmaWdAjVNN5DekZhg9W9OAnntKgo"
+
+	if got := jwk.Thumbprint(); got != want {
+		t.Errorf("Thumbprint got %s, want %s", got, want)
+	}
+
+	jwk.Kid = "k1"
+	jwk.Use = "sig"
+	if got := jwk.Thumbprint(); got != want {
+		t.Errorf("Thumbprint got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,296 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var (
+	ErrJWSJSONInvalid      = errors.New("go-jwt: jws json invalid")
+	ErrJWSJSONNoSignature  = errors.New("go-jwt: jws json has no signature")
+	ErrJWSJSONNotFlattened = errors.New("go-jwt: jws json need only one signature to flatten")
+	ErrJWSJSONVerifyFail   = errors.New("go-jwt: jws json verify fail")
+)
+
+// JWSSignature is one signature of the JWS JSON serialization.
+type JWSSignature struct {
+	Protected string         `json:"protected,omitempty"`
+	Header    map[string]any `json:"header,omitempty"`
+	Signature string         `json:"signature"`
+}
+
+// JWSGeneral is the general JWS JSON serialization.
+type JWSGeneral struct {
+	Payload    string         `json:"payload"`
+	Signatures []JWSSignature `json:"signatures"`
+}
+
+// JWSFlattened is the flattened JWS JSON serialization.
+type JWSFlattened struct {
+	Payload   string         `json:"payload"`
+	Protected string         `json:"protected,omitempty"`
+	Header    map[string]any `json:"header,omitempty"`
+	Signature string         `json:"signature"`
+}
+
+// JWSVerifyKey is the key to verify the signatures with the alg and kid.
+type JWSVerifyKey struct {
+	Alg string
+	Kid string
+	Key any
+}
+
+// JWSVerifyPolicy configures which signatures need to be verified.
+// Every alg in Required need to be verified, and at least MinValid
+// signatures need to be verified. MinValid less than 1 is 1.
+type JWSVerifyPolicy struct {
+	Required []string
+	MinValid int
+}
+
+// JWSJSON signs and verifies a payload with multiple signatures.
+type JWSJSON struct {
+	payload    string
+	signatures []JWSSignature
+	encoder    jwt.IEncoder
+}
+
+func NewJWSJSON(encoder ...jwt.IEncoder) *JWSJSON {
+	var useEncoder jwt.IEncoder
+	if len(encoder) > 0 {
+		useEncoder = encoder[0]
+	} else {
+		useEncoder = jwt.JWTEncoder
+	}
+
+	return &JWSJSON{
+		encoder: useEncoder,
+	}
+}
+
+// SetClaims sets claims as the payload.
+func (j *JWSJSON) SetClaims(claims any) error {
+	encoded, err := j.encoder.JSONEncode(claims)
+	if err != nil {
+		return err
+	}
+
+	j.payload, err = j.encoder.Base64URLEncode(encoded)
+	if err != nil {
+		return err
+	}
+
+	j.signatures = nil
+
+	return nil
+}
+
+// AddSignature signs the payload with the registered signing method alg,
+// header is the unprotected header and kid is put in the protected header.
+func (j *JWSJSON) AddSignature(alg string, kid string, key any, header map[string]any) error {
+	protectedHeader := jwt.TokenHeader{
+		Typ: "JWT",
+		Alg: alg,
+		Kid: kid,
+	}
+
+	encodedHeader, err := j.encoder.JSONEncode(protectedHeader)
+	if err != nil {
+		return err
+	}
+
+	protected, err := j.encoder.Base64URLEncode(encodedHeader)
+	if err != nil {
+		return err
+	}
+
+	signed, err := signWithKey(alg, []byte(protected+"."+j.payload), key)
+	if err != nil {
+		return err
+	}
+
+	signature, err := j.encoder.Base64URLEncode(signed)
+	if err != nil {
+		return err
+	}
+
+	j.signatures = append(j.signatures, JWSSignature{
+		Protected: protected,
+		Header:    header,
+		Signature: signature,
+	})
+
+	return nil
+}
+
+// Signatures returns the signatures.
+func (j *JWSJSON) Signatures() []JWSSignature {
+	return j.signatures
+}
+
+// General returns the general JWS JSON serialization.
+func (j *JWSJSON) General() ([]byte, error) {
+	if len(j.signatures) == 0 {
+		return nil, ErrJWSJSONNoSignature
+	}
+
+	return json.Marshal(JWSGeneral{
+		Payload:    j.payload,
+		Signatures: j.signatures,
+	})
+}
+
+// Flattened returns the flattened JWS JSON serialization.
+func (j *JWSJSON) Flattened() ([]byte, error) {
+	if len(j.signatures) == 0 {
+		return nil, ErrJWSJSONNoSignature
+	}
+	if len(j.signatures) > 1 {
+		return nil, ErrJWSJSONNotFlattened
+	}
+
+	sig := j.signatures[0]
+
+	return json.Marshal(JWSFlattened{
+		Payload:   j.payload,
+		Protected: sig.Protected,
+		Header:    sig.Header,
+		Signature: sig.Signature,
+	})
+}
+
+// Parse parses the general or flattened JWS JSON serialization.
+func (j *JWSJSON) Parse(data []byte) error {
+	var raw struct {
+		JWSFlattened
+		Signatures []JWSSignature `json:"signatures"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	hasFlattened := raw.Protected != "" || raw.Signature != ""
+
+	switch {
+	case len(raw.Signatures) > 0 && !hasFlattened:
+		j.signatures = raw.Signatures
+	case len(raw.Signatures) == 0 && raw.Signature != "":
+		j.signatures = []JWSSignature{
+			{
+				Protected: raw.Protected,
+				Header:    raw.Header,
+				Signature: raw.Signature,
+			},
+		}
+	default:
+		return ErrJWSJSONInvalid
+	}
+
+	j.payload = raw.Payload
+
+	return nil
+}
+
+// GetClaims returns the payload claims.
+func (j *JWSJSON) GetClaims() (jwt.MapClaims, error) {
+	var dst jwt.MapClaims
+	if err := j.GetClaimsT(&dst); err != nil {
+		return jwt.MapClaims{}, err
+	}
+
+	return dst, nil
+}
+
+// GetClaimsT decodes the payload claims to dst.
+func (j *JWSJSON) GetClaimsT(dst any) error {
+	payload, err := j.encoder.Base64URLDecode(j.payload)
+	if err != nil {
+		return err
+	}
+
+	return j.encoder.JSONDecode(payload, dst)
+}
+
+// Verify verifies the signatures with the keys and returns the
+// verified algo names, it fails when the policy is not satisfied.
+// Each key of keys is counted once in MinValid.
+func (j *JWSJSON) Verify(policy JWSVerifyPolicy, keys ...JWSVerifyKey) ([]string, error) {
+	if len(j.signatures) == 0 {
+		return nil, ErrJWSJSONNoSignature
+	}
+
+	// a key is counted once, the copies of one signature are not
+	// independent signers
+	var verified []string
+	used := make(map[int]bool)
+	for _, sig := range j.signatures {
+		index, ok := j.verifySignature(sig, keys)
+		if ok && !used[index] {
+			used[index] = true
+			verified = append(verified, keys[index].Alg)
+		}
+	}
+
+	minValid := policy.MinValid
+	if minValid < 1 {
+		minValid = 1
+	}
+
+	if len(verified) < minValid {
+		return verified, ErrJWSJSONVerifyFail
+	}
+
+	for _, required := range policy.Required {
+		if !containsString(verified, required) {
+			return verified, ErrJWSJSONVerifyFail
+		}
+	}
+
+	return verified, nil
+}
+
+// verifySignature returns the index of the key verifying the signature.
+func (j *JWSJSON) verifySignature(sig JWSSignature, keys []JWSVerifyKey) (int, bool) {
+	t := jwt.NewToken(j.encoder)
+	t.Parse(sig.Protected)
+
+	header, err := t.GetHeader()
+	if err != nil {
+		return 0, false
+	}
+
+	signature, err := j.encoder.Base64URLDecode(sig.Signature)
+	if err != nil {
+		return 0, false
+	}
+
+	msg := []byte(sig.Protected + "." + j.payload)
+
+	for i, key := range keys {
+		if key.Alg != header.Alg {
+			continue
+		}
+		if key.Kid != "" && key.Kid != header.Kid {
+			continue
+		}
+
+		if ok, _ := verifyWithKey(header.Alg, msg, signature, key.Key); ok {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
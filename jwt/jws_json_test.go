@@ -0,0 +1,188 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
+	"github.com/deatil/go-cryptobin/gm/sm2"
+)
+
+func Test_JWSJSON_General(t *testing.T) {
+	sm2Key, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(secp256k1.S256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := map[string]string{
+		"aud": "example.com",
+		"sub": "foo",
+	}
+
+	s := NewJWSJSON()
+	if err := s.SetClaims(claims); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.AddSignature("GmSM2", "cn-1", sm2Key, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddSignature("ES256K", "intl-1", ecKey, map[string]any{"x-region": "intl"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Flattened(); !errors.Is(err, ErrJWSJSONNotFlattened) {
+		t.Errorf("Flattened got %v, want %v", err, ErrJWSJSONNotFlattened)
+	}
+
+	data, err := s.General()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var general JWSGeneral
+	if err := json.Unmarshal(data, &general); err != nil {
+		t.Fatal(err)
+	}
+	if len(general.Signatures) != 2 {
+		t.Fatalf("Signatures got %d, want %d", len(general.Signatures), 2)
+	}
+
+	p := NewJWSJSON()
+	if err := p.Parse(data); err != nil {
+		t.Fatal(err)
+	}
+
+	claims2, err := p.GetClaims()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims2["aud"].(string) != claims["aud"] {
+		t.Errorf("GetClaims aud got %s, want %s", claims2["aud"].(string), claims["aud"])
+	}
+
+	sm2VerifyKey := JWSVerifyKey{Alg: "GmSM2", Kid: "cn-1", Key: &sm2Key.PublicKey}
+	ecVerifyKey := JWSVerifyKey{Alg: "ES256K", Key: &ecKey.PublicKey}
+
+	verified, err := p.Verify(JWSVerifyPolicy{Required: []string{"GmSM2", "ES256K"}}, sm2VerifyKey, ecVerifyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(verified, []string{"GmSM2", "ES256K"}) {
+		t.Errorf("Verify got %v", verified)
+	}
+
+	// domestic verifier only has the GmSM2 key
+	verified, err = p.Verify(JWSVerifyPolicy{Required: []string{"GmSM2"}}, sm2VerifyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(verified, []string{"GmSM2"}) {
+		t.Errorf("Verify got %v", verified)
+	}
+
+	_, err = p.Verify(JWSVerifyPolicy{MinValid: 2}, sm2VerifyKey)
+	if !errors.Is(err, ErrJWSJSONVerifyFail) {
+		t.Errorf("Verify got %v, want %v", err, ErrJWSJSONVerifyFail)
+	}
+
+	_, err = p.Verify(JWSVerifyPolicy{Required: []string{"ES256K"}}, sm2VerifyKey)
+	if !errors.Is(err, ErrJWSJSONVerifyFail) {
+		t.Errorf("Verify got %v, want %v", err, ErrJWSJSONVerifyFail)
+	}
+
+	// the copies of one signature are counted once
+	duplicated := general
+	duplicated.Signatures = []JWSSignature{general.Signatures[0], general.Signatures[0], general.Signatures[0]}
+
+	duplicatedData, err := json.Marshal(duplicated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewJWSJSON()
+	if err := d.Parse(duplicatedData); err != nil {
+		t.Fatal(err)
+	}
+
+	verified, err = d.Verify(JWSVerifyPolicy{MinValid: 2}, sm2VerifyKey, ecVerifyKey)
+	if !errors.Is(err, ErrJWSJSONVerifyFail) {
+		t.Errorf("Verify got %v, want %v", err, ErrJWSJSONVerifyFail)
+	}
+	if !reflect.DeepEqual(verified, []string{"GmSM2"}) {
+		t.Errorf("Verify got %v", verified)
+	}
+
+	wrongKid := JWSVerifyKey{Alg: "GmSM2", Kid: "cn-2", Key: &sm2Key.PublicKey}
+	_, err = p.Verify(JWSVerifyPolicy{}, wrongKid)
+	if !errors.Is(err, ErrJWSJSONVerifyFail) {
+		t.Errorf("Verify got %v, want %v", err, ErrJWSJSONVerifyFail)
+	}
+}
+
+func Test_JWSJSON_Flattened(t *testing.T) {
+	key := []byte("test-key")
+
+	claims := map[string]string{
+		"aud": "example.com",
+	}
+
+	s := NewJWSJSON()
+	if err := s.SetClaims(claims); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddSignature("HSM3", "", key, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := s.Flattened()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var flattened JWSFlattened
+	if err := json.Unmarshal(data, &flattened); err != nil {
+		t.Fatal(err)
+	}
+	if flattened.Signature == "" || flattened.Protected == "" {
+		t.Errorf("Flattened got %s", string(data))
+	}
+
+	p := NewJWSJSON()
+	if err := p.Parse(data); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.Verify(JWSVerifyPolicy{}, JWSVerifyKey{Alg: "HSM3", Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.Verify(JWSVerifyPolicy{}, JWSVerifyKey{Alg: "HSM3", Key: []byte("test-key2")})
+	if !errors.Is(err, ErrJWSJSONVerifyFail) {
+		t.Errorf("Verify got %v, want %v", err, ErrJWSJSONVerifyFail)
+	}
+}
+
+func Test_JWSJSON_ParseInvalid(t *testing.T) {
+	p := NewJWSJSON()
+
+	err := p.Parse([]byte(`{"payload":"e30"}`))
+	if !errors.Is(err, ErrJWSJSONInvalid) {
+		t.Errorf("Parse got %v, want %v", err, ErrJWSJSONInvalid)
+	}
+
+	err = p.Parse([]byte(`{"payload":"e30","signature":"AA","signatures":[{"signature":"AA"}]}`))
+	if !errors.Is(err, ErrJWSJSONInvalid) {
+		t.Errorf("Parse got %v, want %v", err, ErrJWSJSONInvalid)
+	}
+}
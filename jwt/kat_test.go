@@ -0,0 +1,508 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+func fromHexInt(s string) *big.Int {
+	return new(big.Int).SetBytes(fromHex(s))
+}
+
+// GM/T 0003.5 appendix, the signature example on the recommended curve
+// with the default uid 1234567812345678.
+func Test_KAT_GmSM2(t *testing.T) {
+	d := fromHexInt("3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8")
+	k := fromHexInt("59276E27D506861A16680F3AD9C02DCCEF3CC1FA3CDBE4CE6D54B80DEAC1BC21")
+
+	msg := []byte("message digest")
+	sign := "f5a03b0648d2c4630eeac513e1bb81a15944da3827d5b74143ac7eaceee720b3" +
+		"b1b6aa29df212fd8763182bc0d421ca1bb9038fd1f7f42d4840b69c485bbc1aa"
+
+	privateKey, err := sm2.NewPrivateKey(d.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := &privateKey.PublicKey
+
+	x := fromHexInt("09F9DF311E5421A150DD7D161E4BC5C672179FAD1833FC076BB08FF356F35020")
+	y := fromHexInt("CCEA490CE26775A52DC6EA718CC1AA600AED05FBF35E084A6632F6072DA9AD13")
+	if publicKey.X.Cmp(x) != 0 || publicKey.Y.Cmp(y) != 0 {
+		t.Fatalf("PublicKey got (%x, %x), want (%x, %x)", publicKey.X, publicKey.Y, x, y)
+	}
+
+	h := SigningGmSM2.WithNonceSource(NonceSourceFunc(func(*sm2.PrivateKey, []byte) (*big.Int, error) {
+		return k, nil
+	}))
+
+	signed, err := h.Sign(msg, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if signature := hex.EncodeToString(signed); signature != sign {
+		t.Errorf("Sign got %s, want %s", signature, sign)
+	}
+
+	signed, err = h.SignReader(bytes.NewReader(msg), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if signature := hex.EncodeToString(signed); signature != sign {
+		t.Errorf("SignReader got %s, want %s", signature, sign)
+	}
+
+	veri, err := SigningGmSM2.Verify(msg, fromHex(sign), publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !veri {
+		t.Error("Verify fail")
+	}
+
+	if SigningGmSM2.nonce != nil {
+		t.Error("WithNonceSource changed the signer")
+	}
+}
+
+// GM/T 0003.5 appendix, the Z and e values of the signature example.
+func Test_KAT_GmSM2_Digest(t *testing.T) {
+	d := fromHexInt("3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8")
+	k := fromHexInt("59276E27D506861A16680F3AD9C02DCCEF3CC1FA3CDBE4CE6D54B80DEAC1BC21")
+
+	z := "b2e14c5c79c6df5b85f4fe7ed8db7a262b9da7e07ccb0ea9f4747b8ccda8a4f3"
+	e := "f0b43e94ba45accaace692ed534382eb17e6ab5a19ce7b31f4486fdfc0d28640"
+	sign := "f5a03b0648d2c4630eeac513e1bb81a15944da3827d5b74143ac7eaceee720b3" +
+		"b1b6aa29df212fd8763182bc0d421ca1bb9038fd1f7f42d4840b69c485bbc1aa"
+
+	privateKey, err := sm2.NewPrivateKey(d.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := &privateKey.PublicKey
+
+	za, err := CalculateSM2Z(publicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := hex.EncodeToString(za); got != z {
+		t.Errorf("CalculateSM2Z got %s, want %s", got, z)
+	}
+
+	digest, err := CalculateSM2Digest(publicKey, []byte("1234567812345678"), []byte("message digest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := hex.EncodeToString(digest); got != e {
+		t.Errorf("CalculateSM2Digest got %s, want %s", got, e)
+	}
+
+	// the Z of other uid, checked with hashlib
+	za, err = CalculateSM2Z(publicKey, []byte("ALICE123@YAHOO.COM"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := hex.EncodeToString(za), "26db4bc1839bd22e97e1dab667ec5e0a730d5e16521398b4435c576a93afd7ed"; got != want {
+		t.Errorf("CalculateSM2Z got %s, want %s", got, want)
+	}
+
+	h := SigningGmSM2.WithNonceSource(NonceSourceFunc(func(*sm2.PrivateKey, []byte) (*big.Int, error) {
+		return k, nil
+	}))
+
+	signed, err := h.SignDigest(digest, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := hex.EncodeToString(signed); got != sign {
+		t.Errorf("SignDigest got %s, want %s", got, sign)
+	}
+
+	veri, err := SigningGmSM2.VerifyDigest(digest, signed, publicKey)
+	if err != nil || !veri {
+		t.Errorf("VerifyDigest got %v, %v", veri, err)
+	}
+
+	verifier, err := SigningGmSM2.NewVerifier(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	veri, err = verifier.VerifyDigest(digest, signed)
+	if err != nil || !veri {
+		t.Errorf("SM2Verifier VerifyDigest got %v, %v", veri, err)
+	}
+
+	// the signature of the digest is the signature of the message
+	veri, err = SigningGmSM2.Verify([]byte("message digest"), signed, publicKey)
+	if err != nil || !veri {
+		t.Errorf("Verify got %v, %v", veri, err)
+	}
+
+	if _, err := SigningGmSM2.SignDigest(digest[:31], privateKey); err != ErrSignGmSM2DigestInvalid {
+		t.Errorf("SignDigest got %v, want %v", err, ErrSignGmSM2DigestInvalid)
+	}
+
+	// the nil keys are refused, not panic
+	if _, err := SigningGmSM2.SignDigest(digest, nil); err != ErrSM2PrivateKeyInvalid {
+		t.Errorf("SignDigest got %v, want %v", err, ErrSM2PrivateKeyInvalid)
+	}
+	if _, err := SigningGmSM2.VerifyDigest(digest, signed, nil); err != ErrSM2PublicKeyInvalid {
+		t.Errorf("VerifyDigest got %v, want %v", err, ErrSM2PublicKeyInvalid)
+	}
+	if _, err := SigningGmSM2.VerifyDigest(digest, signed, &sm2.PublicKey{Curve: sm2.P256()}); err != ErrSM2PublicKeyInvalid {
+		t.Errorf("VerifyDigest got %v, want %v", err, ErrSM2PublicKeyInvalid)
+	}
+
+	digest[0] ^= 0x01
+	if _, err := SigningGmSM2.VerifyDigest(digest, signed, publicKey); err != ErrSignGmSM2VerifyFail {
+		t.Errorf("VerifyDigest got %v, want %v", err, ErrSignGmSM2VerifyFail)
+	}
+	if _, err := verifier.VerifyDigest(digest, signed); err != ErrSignGmSM2VerifyFail {
+		t.Errorf("SM2Verifier VerifyDigest got %v, want %v", err, ErrSignGmSM2VerifyFail)
+	}
+}
+
+// RFC 6979 section 3.2 nonces with HMAC-SM3, the key of the GM/T 0003.5
+// example. The values are checked with a Python implementation.
+func Test_KAT_GmSM2_Deterministic(t *testing.T) {
+	d := fromHexInt("3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8")
+
+	tests := []struct {
+		msg  string
+		k    string
+		sign string
+	}{
+		{
+			msg: "message digest",
+			k:   "f7d1eea09846e85224fe81ca11453a10827c315a97b924765c3a1e96d9611628",
+			sign: "24858ee71d63e687feefe41f5af80a59f0791eb1dabc2bbe71daf0e57f06c367" +
+				"3d15550de52785a435004c937256ac715c0e04176ac57062c6722fa692f7a491",
+		},
+		{
+			msg: "sample",
+			k:   "4c4f32ee88f1935f367d8cad544c71f030e533df68b64d894e8f81877732c4a9",
+			sign: "a0a6132fad3fa4a1945e04a0ec910600405f8256ff3b9f3cba25ac6aab735190" +
+				"39bc65962595a8314af758ad2ba671833c60b023b0a6ddbcdbf14480b9e17580",
+		},
+		{
+			msg: "test",
+			k:   "ebae2b570683a130bd24c9e348ae0bdbeb1d5ed86078a7dd4c61e2028b56491c",
+			sign: "640ef06dec8193c85df35081d5858db4c2d0320a9ea2157b2cdf458f6442add4" +
+				"1ed62c378092ba168de20df345d0c3482197fa1a23e75ef01134ca5fbfaf2c2a",
+		},
+	}
+
+	privateKey, err := sm2.NewPrivateKey(d.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := SigningGmSM2.WithNonceSource(DeterministicNonce)
+
+	for _, tt := range tests {
+		digest, err := CalculateSM2Digest(&privateKey.PublicKey, nil, []byte(tt.msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		k, err := DeterministicNonce.Nonce(privateKey, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if k.Cmp(fromHexInt(tt.k)) != 0 {
+			t.Errorf("%s: Nonce got %x, want %s", tt.msg, k, tt.k)
+		}
+
+		signed, err := h.Sign([]byte(tt.msg), privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := hex.EncodeToString(signed); got != tt.sign {
+			t.Errorf("%s: Sign got %s, want %s", tt.msg, got, tt.sign)
+		}
+
+		veri, err := SigningGmSM2.Verify([]byte(tt.msg), signed, &privateKey.PublicKey)
+		if err != nil || !veri {
+			t.Errorf("%s: Verify got %v, %v", tt.msg, veri, err)
+		}
+	}
+}
+
+// RFC 4231 inputs, the HMAC-SM3 values are checked with OpenSSL.
+func Test_KAT_HSM3(t *testing.T) {
+	tests := []struct {
+		key  []byte
+		msg  []byte
+		sign string
+	}{
+		{
+			key:  bytes.Repeat([]byte{0x0b}, 20),
+			msg:  []byte("Hi There"),
+			sign: "51b00d1fb49832bfb01c3ce27848e59f871d9ba938dc563b338ca964755cce70",
+		},
+		{
+			key:  []byte("Jefe"),
+			msg:  []byte("what do ya want for nothing?"),
+			sign: "2e87f1d16862e6d964b50a5200bf2b10b764faa9680a296a2405f24bec39f882",
+		},
+		{
+			key:  bytes.Repeat([]byte{0xaa}, 20),
+			msg:  bytes.Repeat([]byte{0xdd}, 50),
+			sign: "dd9421e1c725bdf52ec1aa34edadb3c97f5951a83a2fa93f73a7902bc1dcc777",
+		},
+		{
+			key:  bytes.Repeat([]byte{0xaa}, 131),
+			msg:  []byte("Test Using Larger Than Block-Size Key - Hash Key First"),
+			sign: "b4fd844e13342002f0b2e0690ea7741f1497d993a70494cea601e657bedf67a0",
+		},
+		{
+			key:  bytes.Repeat([]byte{0xaa}, 131),
+			msg:  []byte("This is a test using a larger than block-size key and a larger than block-size data. The key needs to be hashed before being used by the HMAC algorithm."),
+			sign: "5acbdeb0c8c1ef3a99088fe51c0a1d5f4e1c175935f016aee74eb8056db18acb",
+		},
+	}
+
+	for i, td := range tests {
+		signed, err := SigningHSM3.Sign(td.msg, td.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if signature := hex.EncodeToString(signed); signature != td.sign {
+			t.Errorf("[%d] Sign got %s, want %s", i, signature, td.sign)
+		}
+
+		veri, err := SigningHSM3.Verify(td.msg, fromHex(td.sign), td.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !veri {
+			t.Errorf("[%d] Verify fail", i)
+		}
+	}
+}
+
+// RFC 6979 signatures with SHA-256 on secp256k1, low s.
+func Test_KAT_ES256K(t *testing.T) {
+	tests := []struct {
+		d    string
+		msg  string
+		sign string
+	}{
+		{
+			d:   "0000000000000000000000000000000000000000000000000000000000000001",
+			msg: "Satoshi Nakamoto",
+			sign: "934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d8" +
+				"2442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5",
+		},
+		{
+			d:   "f8b8af8ce3c7cca5e300d33939540c10d45ce001b8f252bfbc57ba0342904181",
+			msg: "Alan Turing",
+			sign: "7063ae83e7f62bbb171798131b4a0564b956930092b33b07b395615d9ec7e15c" +
+				"58dfcc1e00a35e1572f366ffe34ba0fc47db1e7189759b9fb233c5b05ab388ea",
+		},
+	}
+
+	curve := secp256k1.S256()
+
+	for i, td := range tests {
+		x, y := curve.ScalarBaseMult(fromHex(td.d))
+		publicKey := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     x,
+			Y:     y,
+		}
+
+		veri, err := SigningES256K.Verify([]byte(td.msg), fromHex(td.sign), publicKey)
+		if err != nil {
+			t.Fatalf("[%d] %v", i, err)
+		}
+
+		if !veri {
+			t.Errorf("[%d] Verify fail", i)
+		}
+
+		// the message is bound
+		if _, err := SigningES256K.Verify([]byte(td.msg+"."), fromHex(td.sign), publicKey); err != ErrSignES256KVerifyFail {
+			t.Errorf("[%d] Verify got %v, want %v", i, err, ErrSignES256KVerifyFail)
+		}
+	}
+}
+
+type tokenFixture struct {
+	Name    string `json:"name"`
+	Library string `json:"library"`
+	Version string `json:"version"`
+	Command string `json:"command"`
+	Alg     string `json:"alg"`
+	Key     string `json:"key"`
+	Secret  string `json:"secret"`
+	Token   string `json:"token"`
+}
+
+func readTokenFixtures(t *testing.T) []tokenFixture {
+	data, err := os.ReadFile(filepath.Join("testdata", "tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fixtures []tokenFixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatal(err)
+	}
+
+	return fixtures
+}
+
+func readTokenFixtureKey(t *testing.T, name string) []byte {
+	pemBytes, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := jwt.ParsePEM(pemBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der
+}
+
+// Test_KAT_ExternalTokens parses the tokens signed by other libraries,
+// see testdata/README.md.
+func Test_KAT_ExternalTokens(t *testing.T) {
+	fixtures := readTokenFixtures(t)
+	if len(fixtures) == 0 {
+		t.Fatal("no token fixtures")
+	}
+
+	for _, fx := range fixtures {
+		t.Run(fx.Name, func(t *testing.T) {
+			if fx.Library == "" || fx.Version == "" || fx.Command == "" {
+				t.Fatal("the fixture has no library, version or command")
+			}
+
+			parse := func(tokenString string) (*jwt.Token, error) {
+				switch fx.Alg {
+				case "GmSM2":
+					publicKey, err := ParseSM2PublicKeyFromDer(readTokenFixtureKey(t, fx.Key))
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					return SigningMethodGmSM2.New().Parse(tokenString, publicKey)
+				case "SM2-SHA256":
+					publicKey, err := ParseSM2PublicKeyFromDer(readTokenFixtureKey(t, fx.Key))
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					return SigningMethodSM2SHA256.New().Parse(tokenString, publicKey)
+				case "ES256K":
+					publicKey, err := ParseECPublicKeyFromDer(readTokenFixtureKey(t, fx.Key))
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					return SigningMethodES256K.New().Parse(tokenString, publicKey)
+				case "BP256R1", "BP384R1", "BP512R1":
+					publicKey, err := ParseECPublicKeyFromDer(readTokenFixtureKey(t, fx.Key))
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					methods := map[string]jwt.JWT[*ecdsa.PrivateKey, *ecdsa.PublicKey]{
+						"BP256R1": SigningMethodBP256R1,
+						"BP384R1": SigningMethodBP384R1,
+						"BP512R1": SigningMethodBP512R1,
+					}
+
+					return methods[fx.Alg].New().Parse(tokenString, publicKey)
+				case "HSM3":
+					return SigningMethodHSM3.New().Parse(tokenString, []byte(fx.Secret))
+				}
+
+				t.Fatalf("unknown alg %s", fx.Alg)
+				return nil, nil
+			}
+
+			parsed, err := parse(fx.Token)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			header, err := parsed.GetHeader()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if header.Alg != fx.Alg {
+				t.Errorf("Alg got %s, want %s", header.Alg, fx.Alg)
+			}
+
+			// a changed payload fails
+			payload, err := jwt.JWTEncoder.Base64URLEncode([]byte(`{"sub":"tampered"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parts := strings.Split(fx.Token, ".")
+			tampered := parts[0] + "." + payload + "." + parts[2]
+			if _, err := parse(tampered); err != jwt.ErrJWTVerifyFail {
+				t.Errorf("Parse tampered got %v, want %v", err, jwt.ErrJWTVerifyFail)
+			}
+		})
+	}
+}
+
+// Test_KAT_ExternalTokens_Deferred reports the libraries of the deferred
+// fixtures, see testdata/README.md.
+func Test_KAT_ExternalTokens_Deferred(t *testing.T) {
+	libraries := make(map[string]bool)
+	for _, fx := range readTokenFixtures(t) {
+		libraries[fx.Library] = true
+	}
+
+	var missing []string
+	for _, library := range []string{"GmSSL", "BouncyCastle", "jose4j"} {
+		if !libraries[library] {
+			missing = append(missing, library)
+		}
+	}
+
+	if len(missing) > 0 {
+		t.Skipf("deferred: no fixtures of %s", strings.Join(missing, ", "))
+	}
+}
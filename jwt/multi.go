@@ -0,0 +1,156 @@
+package jwt
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var ErrMultiKeyNotFound = errors.New("go-jwt: key not found")
+
+// KeyResolver returns the verify key of the token header, the key is a
+// *sm2.PublicKey, *ecdsa.PublicKey or []byte secret matching the alg.
+type KeyResolver func(header jwt.TokenHeader) (any, error)
+
+// MultiParser parses the tokens of more algos, the signing method is
+// picked by the header alg and the key is resolved by the header.
+type MultiParser struct {
+	algs     []string
+	resolver KeyResolver
+	registry *Registry
+	encoder  jwt.IEncoder
+}
+
+// NewMultiParser returns the parser allowing the algs, the package algos
+// when algs is empty.
+func NewMultiParser(resolver KeyResolver, algs ...string) *MultiParser {
+	return &MultiParser{
+		algs:     algs,
+		resolver: resolver,
+		encoder:  jwt.JWTEncoder,
+	}
+}
+
+// with new encoder
+func (p *MultiParser) WithEncoder(encoder jwt.IEncoder) *MultiParser {
+	p.encoder = encoder
+	return p
+}
+
+// WithRegistry uses the signing methods of the registry, the algos of the
+// registry are allowed when algs is empty.
+func (p *MultiParser) WithRegistry(r *Registry) *MultiParser {
+	p.registry = r
+	return p
+}
+
+// Allowed algo names.
+func (p *MultiParser) Algs() []string {
+	if len(p.algs) > 0 {
+		algs := make([]string, len(p.algs))
+		copy(algs, p.algs)
+
+		return algs
+	}
+
+	if p.registry != nil {
+		return p.registry.Algs()
+	}
+
+	algs := make([]string, 0, len(builtinSigners))
+	for alg := range builtinSigners {
+		algs = append(algs, alg)
+	}
+
+	sort.Strings(algs)
+
+	return algs
+}
+
+// IsAllowed reports whether the alg is allowed.
+func (p *MultiParser) IsAllowed(alg string) bool {
+	if isNoneAlg(alg) {
+		return false
+	}
+
+	return containsString(p.Algs(), alg)
+}
+
+// Parse parses the signature and returns the parsed token.
+func (p *MultiParser) Parse(tokenString string) (*jwt.Token, error) {
+	if err := CheckTokenSegments(tokenString, p.encoder); err != nil {
+		return nil, err
+	}
+
+	t := jwt.NewToken(p.encoder)
+	t.Parse(tokenString)
+
+	header, err := t.GetHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(header.Typ) > 0 && header.Typ != "JWT" {
+		return nil, jwt.ErrJWTTypeInvalid
+	}
+
+	if isNoneAlg(header.Alg) {
+		return nil, ErrAllowlistAlgNone
+	}
+
+	if !p.IsAllowed(header.Alg) {
+		return nil, ErrAllowlistAlgNotAllowed
+	}
+
+	if err := CheckCompliance(header.Alg); err != nil {
+		return nil, err
+	}
+
+	if p.resolver == nil {
+		return nil, ErrMultiKeyNotFound
+	}
+
+	key, err := p.resolver(header)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrMultiKeyNotFound
+	}
+
+	if isPublicKeySecret(key) {
+		return nil, ErrAllowlistKeyConfusion
+	}
+
+	signingString, err := t.SigningString()
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := verifyWithKeyFrom(p.registry, header.Alg, []byte(signingString), t.GetSignature(), key)
+	if err == ErrSignerKeyInvalid || err == jwt.ErrJWTMethodInvalid {
+		return nil, ErrAllowlistAlgMismatch
+	}
+	if !ok {
+		return nil, jwt.ErrJWTVerifyFail
+	}
+
+	return t, nil
+}
+
+// ParseClaims parses the token with the parser and decodes the claims.
+func ParseClaims[C any](p *MultiParser, tokenString string) (C, *jwt.Token, error) {
+	var claims C
+
+	t, err := p.Parse(tokenString)
+	if err != nil {
+		return claims, nil, err
+	}
+
+	if err := t.GetClaimsT(&claims); err != nil {
+		return claims, nil, err
+	}
+
+	return claims, t, nil
+}
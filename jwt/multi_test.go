@@ -0,0 +1,147 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func Test_MultiParser(t *testing.T) {
+	sm2Key, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(secp256k1.S256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := map[string]any{
+		"sm2":  &sm2Key.PublicKey,
+		"ec":   &ecKey.PublicKey,
+		"hmac": []byte("test-key"),
+	}
+
+	resolver := func(header jwt.TokenHeader) (any, error) {
+		key, ok := keys[header.Kid]
+		if !ok {
+			return nil, ErrMultiKeyNotFound
+		}
+
+		return key, nil
+	}
+
+	type Claims struct {
+		jwt.RegisteredClaims
+		Role string `json:"role"`
+	}
+
+	claims := map[string]string{
+		"sub":  "foo",
+		"role": "admin",
+	}
+
+	sm2Token, err := SigningMethodGmSM2.New().SignWithHeader(jwt.TokenHeader{Typ: "JWT", Alg: "GmSM2", Kid: "sm2"}, claims, sm2Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecToken, err := SigningMethodES256K.New().SignWithHeader(jwt.TokenHeader{Typ: "JWT", Alg: "ES256K", Kid: "ec"}, claims, ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hmacToken, err := SigningMethodHSM3.New().SignWithHeader(jwt.TokenHeader{Typ: "JWT", Alg: "HSM3", Kid: "hmac"}, claims, []byte("test-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewMultiParser(resolver, "GmSM2", "ES256K", "HSM3")
+
+	for _, tokenString := range []string{sm2Token, ecToken, hmacToken} {
+		got, parsed, err := ParseClaims[Claims](p, tokenString)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Subject != "foo" || got.Role != "admin" || parsed == nil {
+			t.Errorf("ParseClaims got %+v", got)
+		}
+	}
+
+	// the alg is not allowed
+	if _, err := NewMultiParser(resolver, "GmSM2").Parse(ecToken); err != ErrAllowlistAlgNotAllowed {
+		t.Errorf("Parse got %v, want %v", err, ErrAllowlistAlgNotAllowed)
+	}
+
+	// the key of the kid not match the alg
+	keys["ec"] = &sm2Key.PublicKey
+	if _, err := p.Parse(ecToken); err != ErrAllowlistAlgMismatch {
+		t.Errorf("Parse got %v, want %v", err, ErrAllowlistAlgMismatch)
+	}
+
+	keys["ec"] = &ecKey.PublicKey
+
+	// other keys of the same type fail
+	otherKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys["sm2"] = &otherKey.PublicKey
+	if _, err := p.Parse(sm2Token); err != jwt.ErrJWTVerifyFail {
+		t.Errorf("Parse got %v, want %v", err, jwt.ErrJWTVerifyFail)
+	}
+
+	delete(keys, "hmac")
+	if _, err := p.Parse(hmacToken); err != ErrMultiKeyNotFound {
+		t.Errorf("Parse got %v, want %v", err, ErrMultiKeyNotFound)
+	}
+}
+
+func Test_MultiParser_Algs(t *testing.T) {
+	p := NewMultiParser(nil)
+
+	if !p.IsAllowed("GmSM2") || !p.IsAllowed("BP256R1") {
+		t.Error("the package algos should be allowed")
+	}
+
+	if p.IsAllowed("HS256") || p.IsAllowed("none") {
+		t.Error("the other algos should not be allowed")
+	}
+
+	r, err := NewRegistry("ES256K")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.WithRegistry(r)
+	if p.IsAllowed("GmSM2") || !p.IsAllowed("ES256K") {
+		t.Errorf("Algs got %v, want [ES256K]", p.Algs())
+	}
+
+	tokenString, err := SigningMethodHSM3.New().Sign(map[string]string{"sub": "foo"}, []byte("test-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.Parse(tokenString); err != ErrAllowlistAlgNotAllowed {
+		t.Errorf("Parse got %v, want %v", err, ErrAllowlistAlgNotAllowed)
+	}
+
+	// the errors of the resolver are returned
+	errResolver := errors.New("resolver error")
+	p = NewMultiParser(func(header jwt.TokenHeader) (any, error) {
+		return nil, errResolver
+	})
+
+	if _, err := p.Parse(tokenString); err != errResolver {
+		t.Errorf("Parse got %v, want %v", err, errResolver)
+	}
+}
@@ -0,0 +1,181 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/deatil/go-cryptobin/elliptic/brainpool"
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var ErrRegistryAlgUnknown = errors.New("go-jwt: alg not in registry")
+
+// builtinSigners returns the new signers of the package algos, they are
+// not shared with the SigningXXX globals.
+var builtinSigners = map[string]func() any{
+	"GmSM2": func() any {
+		return freezeSigner[*sm2.PrivateKey, *sm2.PublicKey](NewSignGmSM2(32, "GmSM2"))
+	},
+	"SM2-SHA256": func() any {
+		return freezeSigner[*sm2.PrivateKey, *sm2.PublicKey](NewSignSM2WithHash(crypto.SHA256, 32, "SM2-SHA256"))
+	},
+	"ES256K": func() any {
+		return freezeSigner[*ecdsa.PrivateKey, *ecdsa.PublicKey](NewSignES256K(crypto.SHA256, 32, "ES256K"))
+	},
+	"BP256R1": func() any {
+		return freezeSigner[*ecdsa.PrivateKey, *ecdsa.PublicKey](NewSignECDSA(crypto.SHA256, 32, "BP256R1", brainpool.P256r1()))
+	},
+	"BP384R1": func() any {
+		return freezeSigner[*ecdsa.PrivateKey, *ecdsa.PublicKey](NewSignECDSA(crypto.SHA384, 48, "BP384R1", brainpool.P384r1()))
+	},
+	"BP512R1": func() any {
+		return freezeSigner[*ecdsa.PrivateKey, *ecdsa.PublicKey](NewSignECDSA(crypto.SHA512, 64, "BP512R1", brainpool.P512r1()))
+	},
+	"HSM3": func() any {
+		return freezeSigner[[]byte, []byte](NewSignHSM3("HSM3"))
+	},
+}
+
+// Registry is a signing method registry isolated from the global registry
+// of jwt.RegisterSigningMethod. The signers of the registry can not be
+// changed by the callers. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	signers map[string]any
+}
+
+// NewRegistry returns the registry of the package algos, all the algos
+// when algs is empty.
+func NewRegistry(algs ...string) (*Registry, error) {
+	r := &Registry{
+		signers: make(map[string]any),
+	}
+
+	if len(algs) == 0 {
+		for alg := range builtinSigners {
+			algs = append(algs, alg)
+		}
+	}
+
+	for _, alg := range algs {
+		newSigner, ok := builtinSigners[alg]
+		if !ok {
+			return nil, ErrRegistryAlgUnknown
+		}
+
+		r.signers[alg] = newSigner()
+	}
+
+	return r, nil
+}
+
+// Algs returns the algo names of the registry.
+func (r *Registry) Algs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	algs := make([]string, 0, len(r.signers))
+	for alg := range r.signers {
+		algs = append(algs, alg)
+	}
+
+	sort.Strings(algs)
+
+	return algs
+}
+
+// Has reports whether the alg is in the registry.
+func (r *Registry) Has(alg string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.signers[alg]
+	return ok
+}
+
+// Sign signs msg with the signer of the alg picked by the key type.
+func (r *Registry) Sign(alg string, msg []byte, key any) ([]byte, error) {
+	return signWithKeyFrom(r, alg, msg, key)
+}
+
+// Verify verifies signature with the signer of the alg picked by the key type.
+func (r *Registry) Verify(alg string, msg []byte, signature []byte, key any) (bool, error) {
+	return verifyWithKeyFrom(r, alg, msg, signature, key)
+}
+
+func (r *Registry) get(alg string) any {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.signers[alg]
+}
+
+// AddSigner adds the signer to the registry with the signer alg name,
+// the signer should not be changed after added.
+func AddSigner[S any, V any](r *Registry, signer jwt.ISigner[S, V]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.signers[signer.Alg()] = freezeSigner[S, V](signer)
+}
+
+// GetRegistrySigningMethod returns the signer of the alg in the registry,
+// or nil when the alg is not in the registry or has other key types.
+// A nil registry is the global registry.
+func GetRegistrySigningMethod[S any, V any](r *Registry, alg string) jwt.ISigner[S, V] {
+	if r == nil {
+		return jwt.GetSigningMethod[S, V](alg)
+	}
+
+	signer, ok := r.get(alg).(jwt.ISigner[S, V])
+	if !ok {
+		return nil
+	}
+
+	return signer
+}
+
+// NewRegistryJWT returns the JWT of the alg in the registry.
+func NewRegistryJWT[S any, V any](r *Registry, alg string, encoder jwt.IEncoder) (jwt.JWT[S, V], error) {
+	signer := GetRegistrySigningMethod[S, V](r, alg)
+	if signer == nil {
+		return jwt.JWT[S, V]{}, ErrRegistryAlgUnknown
+	}
+
+	return jwt.NewJWT[S, V](signer, encoder), nil
+}
+
+// frozenSigner hides the fields of the signer.
+type frozenSigner[S any, V any] struct {
+	signer jwt.ISigner[S, V]
+}
+
+func freezeSigner[S any, V any](signer jwt.ISigner[S, V]) *frozenSigner[S, V] {
+	return &frozenSigner[S, V]{
+		signer: signer,
+	}
+}
+
+// Signer algo name.
+func (s *frozenSigner[S, V]) Alg() string {
+	return s.signer.Alg()
+}
+
+// Signer signed bytes length.
+func (s *frozenSigner[S, V]) SignLength() int {
+	return s.signer.SignLength()
+}
+
+// Sign implements token signing for the Signer.
+func (s *frozenSigner[S, V]) Sign(msg []byte, key S) ([]byte, error) {
+	return s.signer.Sign(msg, key)
+}
+
+// Verify implements token verification for the Signer.
+func (s *frozenSigner[S, V]) Verify(msg []byte, signature []byte, key V) (bool, error) {
+	return s.signer.Verify(msg, signature, key)
+}
@@ -0,0 +1,163 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"reflect"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func Test_Registry(t *testing.T) {
+	r, err := NewRegistry("HSM3", "GmSM2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if algs := r.Algs(); !reflect.DeepEqual(algs, []string{"GmSM2", "HSM3"}) {
+		t.Errorf("Algs got %v", algs)
+	}
+
+	if r.Has("ES256K") {
+		t.Error("ES256K should not be in the registry")
+	}
+
+	if _, err := NewRegistry("GmSM2", "RS256"); err != ErrRegistryAlgUnknown {
+		t.Errorf("NewRegistry got %v, want %v", err, ErrRegistryAlgUnknown)
+	}
+
+	all, err := NewRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if algs := all.Algs(); len(algs) != len(builtinSigners) {
+		t.Errorf("Algs got %v", algs)
+	}
+
+	if s := GetRegistrySigningMethod[*sm2.PrivateKey, *sm2.PublicKey](r, "GmSM2"); s == nil || s.Alg() != "GmSM2" {
+		t.Errorf("GetRegistrySigningMethod got %v", s)
+	}
+
+	// other key types and algs are nil
+	if s := GetRegistrySigningMethod[[]byte, []byte](r, "GmSM2"); s != nil {
+		t.Errorf("GetRegistrySigningMethod got %v, want nil", s)
+	}
+	if s := GetRegistrySigningMethod[*ecdsa.PrivateKey, *ecdsa.PublicKey](r, "ES256K"); s != nil {
+		t.Errorf("GetRegistrySigningMethod got %v, want nil", s)
+	}
+
+	// the nil registry is the global registry
+	if s := GetRegistrySigningMethod[*ecdsa.PrivateKey, *ecdsa.PublicKey](nil, "ES256K"); s == nil {
+		t.Error("GetRegistrySigningMethod of the global registry got nil")
+	}
+}
+
+func Test_Registry_Immutable(t *testing.T) {
+	r, err := NewRegistry("GmSM2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := GetRegistrySigningMethod[*sm2.PrivateKey, *sm2.PublicKey](r, "GmSM2")
+	if _, ok := s.(*SignGmSM2); ok {
+		t.Fatal("the registry signer can be changed")
+	}
+
+	// the changed globals are not used by the registry
+	name := SigningGmSM2.Name
+	SigningGmSM2.Name = "changed"
+	defer func() {
+		SigningGmSM2.Name = name
+	}()
+
+	if alg := s.Alg(); alg != "GmSM2" {
+		t.Errorf("Alg got %s, want %s", alg, "GmSM2")
+	}
+
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	method, err := NewRegistryJWT[*sm2.PrivateKey, *sm2.PublicKey](r, "GmSM2", jwt.JWTEncoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenString, err := method.New().Sign(map[string]string{"sub": "foo"}, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, err := jwt.GetTokenHeader(tokenString)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Alg != "GmSM2" {
+		t.Errorf("Alg got %s, want %s", header.Alg, "GmSM2")
+	}
+
+	if _, err := method.New().Parse(tokenString, &privateKey.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewRegistryJWT[*sm2.PrivateKey, *sm2.PublicKey](r, "ES256K", jwt.JWTEncoder); err != ErrRegistryAlgUnknown {
+		t.Errorf("NewRegistryJWT got %v, want %v", err, ErrRegistryAlgUnknown)
+	}
+}
+
+func Test_Registry_SignVerify(t *testing.T) {
+	r, err := NewRegistry("GmSM2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := []byte("test-data")
+
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := r.Sign("GmSM2", msg, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := r.Verify("GmSM2", msg, signed, &privateKey.PublicKey)
+	if err != nil || !ok {
+		t.Errorf("Verify got %v, %v", ok, err)
+	}
+
+	// ES256K is registered globally, but not in the registry
+	ecKey, err := ecdsa.GenerateKey(secp256k1.S256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Sign("ES256K", msg, ecKey); err != jwt.ErrJWTMethodInvalid {
+		t.Errorf("Sign got %v, want %v", err, jwt.ErrJWTMethodInvalid)
+	}
+
+	// the custom signers
+	AddSigner[*ecdsa.PrivateKey, *ecdsa.PublicKey](r, NewSignES256K(SigningES256K.Hash, 32, "ES256K-custom"))
+
+	signed, err = r.Sign("ES256K-custom", msg, ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err = r.Verify("ES256K-custom", msg, signed, &ecKey.PublicKey)
+	if err != nil || !ok {
+		t.Errorf("Verify got %v, %v", ok, err)
+	}
+
+	if s := jwt.GetSigningMethod[*ecdsa.PrivateKey, *ecdsa.PublicKey]("ES256K-custom"); s != nil {
+		t.Error("the custom signer is registered globally")
+	}
+}
@@ -3,10 +3,15 @@ package jwt
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"errors"
+	"hash"
+	"io"
 	"math/big"
+	"sync"
 
+	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
 	"github.com/deatil/go-jwt/jwt"
 )
 
@@ -25,20 +30,32 @@ func init() {
 var (
 	ErrSignES256KSignLengthInvalid = errors.New("go-jwt: sign length error")
 	ErrSignES256KVerifyFail        = errors.New("go-jwt: SignES256K Verify fail")
+	ErrSignES256KKeyInvalid        = errors.New("go-jwt: SignES256K key is not a valid key of the curve")
 )
 
-// SignES256K implements the SM2 family of signing methods.
+// SignES256K implements the ECDSA family of signing methods,
+// the curve is secp256k1 for ES256K.
 type SignES256K struct {
 	Name    string
 	Hash    crypto.Hash
 	KeySize int
+
+	// Curve is the curve of the keys, the keys of other curves are refused.
+	Curve elliptic.Curve
 }
 
 func NewSignES256K(hash crypto.Hash, keySize int, name string) *SignES256K {
+	return NewSignECDSA(hash, keySize, name, secp256k1.S256())
+}
+
+// NewSignECDSA returns the ECDSA Signer of the curve, the keySize is the
+// byte size of the curve order.
+func NewSignECDSA(hash crypto.Hash, keySize int, name string, curve elliptic.Curve) *SignES256K {
 	return &SignES256K{
 		Name:    name,
 		Hash:    hash,
 		KeySize: keySize,
+		Curve:   curve,
 	}
 }
 
@@ -54,10 +71,89 @@ func (s *SignES256K) SignLength() int {
 
 // Sign implements token signing for the Signer.
 func (s *SignES256K) Sign(msg []byte, key *ecdsa.PrivateKey) ([]byte, error) {
-	hasher := s.Hash.New()
-	hasher.Write([]byte(msg))
+	if err := CheckCompliance(s.Name); err != nil {
+		return nil, err
+	}
+
+	if !isValidECPrivateKey(key, s.Curve) {
+		return nil, ErrSignES256KKeyInvalid
+	}
+
+	hasher := getHasher(s.Hash)
+	defer putHasher(s.Hash, hasher)
+
+	hasher.Write(msg)
+
+	return s.signDigest(hasher.Sum(nil), key)
+}
+
+// Verify implements token verification for the Signer.
+func (s *SignES256K) Verify(msg []byte, signature []byte, key *ecdsa.PublicKey) (bool, error) {
+	if err := CheckCompliance(s.Name); err != nil {
+		return false, err
+	}
+
+	// check length and key before hashing the message
+	signLength := s.SignLength()
+	if len(signature) != signLength {
+		return false, ErrSignES256KSignLengthInvalid
+	}
+
+	if !isValidECPublicKey(key, s.Curve) {
+		return false, ErrSignES256KKeyInvalid
+	}
+
+	hasher := getHasher(s.Hash)
+	defer putHasher(s.Hash, hasher)
+
+	hasher.Write(msg)
+
+	return s.verifyDigest(hasher.Sum(nil), signature, key)
+}
+
+// SignReader implements signing for the message read from r,
+// the message is hashed incrementally.
+func (s *SignES256K) SignReader(r io.Reader, key *ecdsa.PrivateKey) ([]byte, error) {
+	if err := CheckCompliance(s.Name); err != nil {
+		return nil, err
+	}
 
-	rr, ss, err := ecdsa.Sign(rand.Reader, key, hasher.Sum(nil))
+	if !isValidECPrivateKey(key, s.Curve) {
+		return nil, ErrSignES256KKeyInvalid
+	}
+
+	hasher := getHasher(s.Hash)
+	defer putHasher(s.Hash, hasher)
+
+	if _, err := io.Copy(hasher, r); err != nil {
+		return nil, err
+	}
+
+	return s.signDigest(hasher.Sum(nil), key)
+}
+
+// VerifyReader implements verification for the message read from r.
+func (s *SignES256K) VerifyReader(r io.Reader, signature []byte, key *ecdsa.PublicKey) (bool, error) {
+	if err := CheckCompliance(s.Name); err != nil {
+		return false, err
+	}
+
+	if !isValidECPublicKey(key, s.Curve) {
+		return false, ErrSignES256KKeyInvalid
+	}
+
+	hasher := getHasher(s.Hash)
+	defer putHasher(s.Hash, hasher)
+
+	if _, err := io.Copy(hasher, r); err != nil {
+		return false, err
+	}
+
+	return s.verifyDigest(hasher.Sum(nil), signature, key)
+}
+
+func (s *SignES256K) signDigest(digest []byte, key *ecdsa.PrivateKey) ([]byte, error) {
+	rr, ss, err := ecdsa.Sign(rand.Reader, key, digest)
 	if err != nil {
 		return nil, err
 	}
@@ -71,23 +167,44 @@ func (s *SignES256K) Sign(msg []byte, key *ecdsa.PrivateKey) ([]byte, error) {
 	return signed, nil
 }
 
-// Verify implements token verification for the Signer.
-func (s *SignES256K) Verify(msg []byte, signature []byte, key *ecdsa.PublicKey) (bool, error) {
+func (s *SignES256K) verifyDigest(digest []byte, signature []byte, key *ecdsa.PublicKey) (bool, error) {
 	signLength := s.SignLength()
 	if len(signature) != signLength {
 		return false, ErrSignES256KSignLengthInvalid
 	}
 
-	rr := big.NewInt(0).SetBytes(signature[:s.KeySize])
-	ss := big.NewInt(0).SetBytes(signature[s.KeySize:])
-
-	hasher := s.Hash.New()
-	hasher.Write([]byte(msg))
+	var rr, ss big.Int
+	rr.SetBytes(signature[:s.KeySize])
+	ss.SetBytes(signature[s.KeySize:])
 
-	verifyStatus := ecdsa.Verify(key, hasher.Sum(nil), rr, ss)
+	verifyStatus := ecdsa.Verify(key, digest, &rr, &ss)
 	if !verifyStatus {
 		return false, ErrSignES256KVerifyFail
 	}
 
 	return true, nil
 }
+
+// hasher pools by hash, the hashers are reset before put back.
+var hasherPools sync.Map
+
+func getHasher(h crypto.Hash) hash.Hash {
+	pool, ok := hasherPools.Load(h)
+	if !ok {
+		pool, _ = hasherPools.LoadOrStore(h, &sync.Pool{
+			New: func() any {
+				return h.New()
+			},
+		})
+	}
+
+	return pool.(*sync.Pool).Get().(hash.Hash)
+}
+
+func putHasher(h crypto.Hash, hasher hash.Hash) {
+	hasher.Reset()
+
+	if pool, ok := hasherPools.Load(h); ok {
+		pool.(*sync.Pool).Put(hasher)
+	}
+}
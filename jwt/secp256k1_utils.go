@@ -2,11 +2,15 @@ package jwt
 
 import (
 	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
 
 	"github.com/deatil/go-cryptobin/elliptic/secp256k1"
 	pubkey_ecdsa "github.com/deatil/go-cryptobin/pubkey/ecdsa"
 )
 
+var ErrECKeyCurveInvalid = errors.New("go-jwt: EC key is not a valid key of the curve")
+
 func init() {
 	pubkey_ecdsa.AddNamedCurve(secp256k1.S256(), secp256k1.OIDNamedCurveSecp256k1)
 }
@@ -33,3 +37,67 @@ func ParseECPublicKeyFromDer(der []byte) (*ecdsa.PublicKey, error) {
 
 	return pkey, nil
 }
+
+// ParseECPrivateKeyFromDerWithCurve parses a PEM encoded PKCS1 or PKCS8
+// private key, and checks the key is a valid key of the curve
+func ParseECPrivateKeyFromDerWithCurve(der []byte, curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	pkey, err := ParseECPrivateKeyFromDer(der)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isValidECPrivateKey(pkey, curve) {
+		return nil, ErrECKeyCurveInvalid
+	}
+
+	return pkey, nil
+}
+
+// ParseECPublicKeyFromDerWithCurve parses a PEM encoded PKCS8 public key,
+// and checks the key is a valid point of the curve
+func ParseECPublicKeyFromDerWithCurve(der []byte, curve elliptic.Curve) (*ecdsa.PublicKey, error) {
+	pkey, err := ParseECPublicKeyFromDer(der)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isValidECPublicKey(pkey, curve) {
+		return nil, ErrECKeyCurveInvalid
+	}
+
+	return pkey, nil
+}
+
+// isValidECPublicKey reports whether the key is a point of the curve,
+// with the coordinates in range and not the identity.
+func isValidECPublicKey(key *ecdsa.PublicKey, curve elliptic.Curve) bool {
+	if key == nil || key.X == nil || key.Y == nil || key.Curve == nil {
+		return false
+	}
+
+	if curve != nil && key.Curve != curve {
+		return false
+	}
+
+	P := key.Curve.Params().P
+	if key.X.Sign() < 0 || key.X.Cmp(P) >= 0 || key.Y.Sign() < 0 || key.Y.Cmp(P) >= 0 {
+		return false
+	}
+
+	// (0, 0) is the identity of crypto/elliptic
+	if key.X.Sign() == 0 && key.Y.Sign() == 0 {
+		return false
+	}
+
+	return key.Curve.IsOnCurve(key.X, key.Y)
+}
+
+// isValidECPrivateKey reports whether the key is a valid key of the curve.
+func isValidECPrivateKey(key *ecdsa.PrivateKey, curve elliptic.Curve) bool {
+	if key == nil || key.D == nil || !isValidECPublicKey(&key.PublicKey, curve) {
+		return false
+	}
+
+	N := key.Curve.Params().N
+	return key.D.Sign() > 0 && key.D.Cmp(N) < 0
+}
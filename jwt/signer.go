@@ -0,0 +1,79 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var ErrSignerKeyInvalid = errors.New("go-jwt: signer key type invalid")
+
+// signWithKey signs msg with the registered signing method
+// picked by alg and the key type.
+func signWithKey(alg string, msg []byte, key any) ([]byte, error) {
+	return signWithKeyFrom(nil, alg, msg, key)
+}
+
+// verifyWithKey verifies signature with the registered signing method
+// picked by alg and the key type.
+func verifyWithKey(alg string, msg []byte, signature []byte, key any) (bool, error) {
+	return verifyWithKeyFrom(nil, alg, msg, signature, key)
+}
+
+// signWithKeyFrom signs msg with the signing method of the registry,
+// a nil registry is the global registry.
+func signWithKeyFrom(r *Registry, alg string, msg []byte, key any) ([]byte, error) {
+	if isNoneAlg(alg) {
+		return nil, jwt.ErrJWTMethodInvalid
+	}
+
+	switch k := key.(type) {
+	case *sm2.PrivateKey:
+		return signWith[*sm2.PrivateKey, *sm2.PublicKey](r, alg, msg, k)
+	case *ecdsa.PrivateKey:
+		return signWith[*ecdsa.PrivateKey, *ecdsa.PublicKey](r, alg, msg, k)
+	case []byte:
+		return signWith[[]byte, []byte](r, alg, msg, k)
+	}
+
+	return nil, ErrSignerKeyInvalid
+}
+
+// verifyWithKeyFrom verifies signature with the signing method of the
+// registry, a nil registry is the global registry.
+func verifyWithKeyFrom(r *Registry, alg string, msg []byte, signature []byte, key any) (bool, error) {
+	if isNoneAlg(alg) {
+		return false, jwt.ErrJWTMethodInvalid
+	}
+
+	switch k := key.(type) {
+	case *sm2.PublicKey:
+		return verifyWith[*sm2.PrivateKey, *sm2.PublicKey](r, alg, msg, signature, k)
+	case *ecdsa.PublicKey:
+		return verifyWith[*ecdsa.PrivateKey, *ecdsa.PublicKey](r, alg, msg, signature, k)
+	case []byte:
+		return verifyWith[[]byte, []byte](r, alg, msg, signature, k)
+	}
+
+	return false, ErrSignerKeyInvalid
+}
+
+func signWith[S any, V any](r *Registry, alg string, msg []byte, key S) ([]byte, error) {
+	signer := GetRegistrySigningMethod[S, V](r, alg)
+	if signer == nil {
+		return nil, jwt.ErrJWTMethodInvalid
+	}
+
+	return signer.Sign(msg, key)
+}
+
+func verifyWith[S any, V any](r *Registry, alg string, msg []byte, signature []byte, key V) (bool, error) {
+	signer := GetRegistrySigningMethod[S, V](r, alg)
+	if signer == nil {
+		return false, jwt.ErrJWTMethodInvalid
+	}
+
+	return signer.Verify(msg, signature, key)
+}
@@ -1,10 +1,16 @@
 package jwt
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/rand"
 	"errors"
+	"hash"
+	"io"
+	"math/big"
 
 	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-cryptobin/hash/sm3"
 	"github.com/deatil/go-jwt/jwt"
 )
 
@@ -23,12 +29,52 @@ func init() {
 var (
 	ErrSignGmSM2SignLengthInvalid = errors.New("go-jwt: sign length error")
 	ErrSignGmSM2VerifyFail        = errors.New("go-jwt: SignGmSM2 Verify fail")
+	ErrSignGmSM2DigestInvalid     = errors.New("go-jwt: SignGmSM2 digest length error")
+	ErrSignGmSM2HashInvalid       = errors.New("go-jwt: SignGmSM2 hash invalid")
+	ErrSignGmSM2NonceInvalid      = errors.New("go-jwt: SignGmSM2 nonce invalid")
 )
 
+// NonceSource returns the nonce k to sign the digest with the key.
+type NonceSource interface {
+	Nonce(key *sm2.PrivateKey, digest []byte) (*big.Int, error)
+}
+
+// NonceSequence is the NonceSource of the nonces of the digest in order.
+// The signer uses the next nonce when a nonce makes an invalid signature,
+// as RFC 6979 section 3.2 step h.
+type NonceSequence interface {
+	NonceSource
+	Nonces(key *sm2.PrivateKey, digest []byte) (next func() *big.Int, err error)
+}
+
+// sm2NonceRetries is the max nonces of a NonceSequence tried to sign.
+const sm2NonceRetries = 64
+
+// NonceSourceFunc is an adapter to use a func as the NonceSource.
+type NonceSourceFunc func(key *sm2.PrivateKey, digest []byte) (*big.Int, error)
+
+// Nonce returns f(key, digest).
+func (f NonceSourceFunc) Nonce(key *sm2.PrivateKey, digest []byte) (*big.Int, error) {
+	return f(key, digest)
+}
+
 // SignGmSM2 implements the SM2 family of signing methods.
 type SignGmSM2 struct {
 	Name    string
 	KeySize int
+
+	// Hash is the hash of Z and the message, zero is SM3.
+	// GmSM2 is SM3 only, the other hashes are the other algos.
+	Hash crypto.Hash
+
+	// nonce is the nonce source, nil uses crypto/rand.
+	nonce NonceSource
+
+	// validateKey validates the public key before verifying.
+	validateKey bool
+
+	// legacy accepts the legacy signature encodings when verifying.
+	legacy bool
 }
 
 func NewSignGmSM2(keySize int, name string) *SignGmSM2 {
@@ -38,6 +84,46 @@ func NewSignGmSM2(keySize int, name string) *SignGmSM2 {
 	}
 }
 
+// NewSignSM2WithHash returns the SM2 Signer hashing Z and the message with
+// the hash, the name must not be GmSM2, GmSM2 is SM3 only.
+func NewSignSM2WithHash(hash crypto.Hash, keySize int, name string) *SignGmSM2 {
+	return &SignGmSM2{
+		Name:    name,
+		KeySize: keySize,
+		Hash:    hash,
+	}
+}
+
+// WithNonceSource returns a copy of the Signer signing with the nonce source,
+// DeterministicNonce is the RFC 6979 style nonce. A fixed nonce leaks the
+// private key, it is only for known-answer tests.
+func (s *SignGmSM2) WithNonceSource(nonce NonceSource) *SignGmSM2 {
+	signer := *s
+	signer.nonce = nonce
+
+	return &signer
+}
+
+// WithKeyValidation returns a copy of the Signer validating the public key
+// with ValidateSM2PublicKey before verifying. The keys parsed with
+// ParseSM2PublicKeyFromDer are validated already.
+func (s *SignGmSM2) WithKeyValidation(validate bool) *SignGmSM2 {
+	signer := *s
+	signer.validateKey = validate
+
+	return &signer
+}
+
+// WithLegacySignatures returns a copy of the Signer accepting the base64url
+// DER and the hex r || s signatures of the legacy systems when verifying.
+// The other legacy tokens are converted with ConvertLegacySM2Token.
+func (s *SignGmSM2) WithLegacySignatures(legacy bool) *SignGmSM2 {
+	signer := *s
+	signer.legacy = legacy
+
+	return &signer
+}
+
 // Signer algo name.
 func (s *SignGmSM2) Alg() string {
 	return s.Name
@@ -50,25 +136,330 @@ func (s *SignGmSM2) SignLength() int {
 
 // Sign implements token signing for the Signer.
 func (s *SignGmSM2) Sign(msg []byte, key *sm2.PrivateKey) ([]byte, error) {
-	signed, err := sm2.SignBytes(rand.Reader, key, msg, nil)
+	if err := CheckCompliance(s.Name); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkHash(); err != nil {
+		return nil, err
+	}
+
+	digest, err := s.hashReader(bytes.NewReader(msg), &key.PublicKey)
 	if err != nil {
 		return nil, err
 	}
 
-	return signed, nil
+	return s.signDigest(digest, key)
 }
 
 // Verify implements token verification for the Signer.
 func (s *SignGmSM2) Verify(msg []byte, signature []byte, key *sm2.PublicKey) (bool, error) {
+	if err := CheckCompliance(s.Name); err != nil {
+		return false, err
+	}
+
+	if err := s.checkHash(); err != nil {
+		return false, err
+	}
+
+	if s.legacy {
+		normalized, err := normalizeLegacySignature(signature, s.KeySize)
+		if err != nil {
+			return false, err
+		}
+
+		signature = normalized
+	}
+
 	signLength := s.SignLength()
 	if len(signature) != signLength {
 		return false, ErrSignGmSM2SignLengthInvalid
 	}
 
-	verifyStatus := sm2.VerifyBytes(key, msg, signature, nil)
+	if s.validateKey {
+		if err := ValidateSM2PublicKey(key); err != nil {
+			return false, err
+		}
+	}
+
+	digest, err := s.hashReader(bytes.NewReader(msg), key)
+	if err != nil {
+		return false, err
+	}
+
+	return s.verifyDigest(digest, signature, key)
+}
+
+// SignReader implements signing for the message read from r,
+// the message is hashed incrementally with SM3(Z || M).
+func (s *SignGmSM2) SignReader(r io.Reader, key *sm2.PrivateKey) ([]byte, error) {
+	if err := CheckCompliance(s.Name); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkHash(); err != nil {
+		return nil, err
+	}
+
+	digest, err := s.hashReader(r, &key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.signDigest(digest, key)
+}
+
+// VerifyReader implements verification for the message read from r.
+func (s *SignGmSM2) VerifyReader(r io.Reader, signature []byte, key *sm2.PublicKey) (bool, error) {
+	if err := CheckCompliance(s.Name); err != nil {
+		return false, err
+	}
+
+	if err := s.checkHash(); err != nil {
+		return false, err
+	}
+
+	if s.legacy {
+		normalized, err := normalizeLegacySignature(signature, s.KeySize)
+		if err != nil {
+			return false, err
+		}
+
+		signature = normalized
+	}
+
+	signLength := s.SignLength()
+	if len(signature) != signLength {
+		return false, ErrSignGmSM2SignLengthInvalid
+	}
+
+	if s.validateKey {
+		if err := ValidateSM2PublicKey(key); err != nil {
+			return false, err
+		}
+	}
+
+	digest, err := s.hashReader(r, key)
+	if err != nil {
+		return false, err
+	}
+
+	return s.verifyDigest(digest, signature, key)
+}
+
+// CalculateDigest returns the digest e = H(Z || M) to sign and verify with
+// SignDigest and VerifyDigest, Z and e are of the hash of the Signer, the
+// uid is 1234567812345678 when nil.
+func (s *SignGmSM2) CalculateDigest(key *sm2.PublicKey, uid []byte, msg []byte) ([]byte, error) {
+	if err := s.checkHash(); err != nil {
+		return nil, err
+	}
+
+	za, err := s.calculateZWithUID(key, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := s.newHash()
+	hasher.Write(za)
+	hasher.Write(msg)
+
+	return hasher.Sum(nil), nil
+}
+
+// SignDigest signs the digest e = H(Z || M) computed by the caller,
+// see CalculateDigest. The digest is not checked with the key.
+func (s *SignGmSM2) SignDigest(digest []byte, key *sm2.PrivateKey) ([]byte, error) {
+	if err := CheckCompliance(s.Name); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkHash(); err != nil {
+		return nil, err
+	}
+
+	if len(digest) != s.newHash().Size() {
+		return nil, ErrSignGmSM2DigestInvalid
+	}
+
+	if key == nil || key.Curve == nil || key.D == nil {
+		return nil, ErrSM2PrivateKeyInvalid
+	}
+
+	return s.signDigest(digest, key)
+}
+
+// VerifyDigest verifies the signature of the digest e = H(Z || M).
+func (s *SignGmSM2) VerifyDigest(digest []byte, signature []byte, key *sm2.PublicKey) (bool, error) {
+	if err := CheckCompliance(s.Name); err != nil {
+		return false, err
+	}
+
+	if err := s.checkHash(); err != nil {
+		return false, err
+	}
+
+	if len(digest) != s.newHash().Size() {
+		return false, ErrSignGmSM2DigestInvalid
+	}
+
+	if key == nil || key.Curve == nil || key.X == nil || key.Y == nil {
+		return false, ErrSM2PublicKeyInvalid
+	}
+
+	if s.legacy {
+		normalized, err := normalizeLegacySignature(signature, s.KeySize)
+		if err != nil {
+			return false, err
+		}
+
+		signature = normalized
+	}
+
+	signLength := s.SignLength()
+	if len(signature) != signLength {
+		return false, ErrSignGmSM2SignLengthInvalid
+	}
+
+	if s.validateKey {
+		if err := ValidateSM2PublicKey(key); err != nil {
+			return false, err
+		}
+	}
+
+	return s.verifyDigest(digest, signature, key)
+}
+
+func (s *SignGmSM2) verifyDigest(digest []byte, signature []byte, key *sm2.PublicKey) (bool, error) {
+	rr, ss, err := sm2.UnmarshalSignatureBytes(key.Curve, signature)
+	if err != nil {
+		return false, err
+	}
+
+	verifyStatus := sm2.VerifyLegacy(key, digest, rr, ss)
 	if !verifyStatus {
 		return false, ErrSignGmSM2VerifyFail
 	}
 
 	return true, nil
 }
+
+// signDigest signs the digest with the nonce source or crypto/rand,
+// and returns the r || s signature.
+func (s *SignGmSM2) signDigest(digest []byte, key *sm2.PrivateKey) ([]byte, error) {
+	if s.nonce != nil {
+		return s.signDigestWithNonce(digest, key)
+	}
+
+	rr, ss, err := sm2.SignLegacy(rand.Reader, key, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return sm2.MarshalSignatureBytes(key.Curve, rr, ss)
+}
+
+// signDigestWithNonce signs the digest with the nonce source. The next
+// nonce of a NonceSequence is used when k is not in [1, n-1], r is 0,
+// r + k is n or s is 0, the other nonce sources fail.
+func (s *SignGmSM2) signDigestWithNonce(digest []byte, key *sm2.PrivateKey) ([]byte, error) {
+	next := func() (*big.Int, error) {
+		return s.nonce.Nonce(key, digest)
+	}
+
+	retries := 1
+	if seq, ok := s.nonce.(NonceSequence); ok {
+		nonces, err := seq.Nonces(key, digest)
+		if err != nil {
+			return nil, err
+		}
+
+		next = func() (*big.Int, error) {
+			return nonces(), nil
+		}
+		retries = sm2NonceRetries
+	}
+
+	N := key.Curve.Params().N
+
+	for i := 0; i < retries; i++ {
+		k, err := next()
+		if err != nil {
+			return nil, err
+		}
+
+		// k = 0 makes s = -d r / (1 + d), it leaks the private key
+		if k == nil || k.Sign() <= 0 || k.Cmp(N) >= 0 {
+			continue
+		}
+
+		// SignLegacyUsingK does not refuse r = 0
+		rr, ss, err := sm2.SignLegacyUsingK(k, key, digest)
+		if err == nil && rr.Sign() != 0 {
+			return sm2.MarshalSignatureBytes(key.Curve, rr, ss)
+		}
+	}
+
+	return nil, ErrSignGmSM2NonceInvalid
+}
+
+// hashReader returns H(Z || M) with the default uid.
+func (s *SignGmSM2) hashReader(r io.Reader, key *sm2.PublicKey) ([]byte, error) {
+	za, err := s.calculateZ(key)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := s.newHash()
+	hasher.Write(za)
+
+	if _, err := io.Copy(hasher, r); err != nil {
+		return nil, err
+	}
+
+	return hasher.Sum(nil), nil
+}
+
+// checkHash checks the hash is available, and GmSM2 is SM3 only.
+func (s *SignGmSM2) checkHash() error {
+	if s.Hash == 0 {
+		return nil
+	}
+
+	if s.Name == "GmSM2" || !s.Hash.Available() {
+		return ErrSignGmSM2HashInvalid
+	}
+
+	return nil
+}
+
+func (s *SignGmSM2) newHash() hash.Hash {
+	if s.Hash == 0 {
+		return sm3.New()
+	}
+
+	return s.Hash.New()
+}
+
+// calculateZ returns Z of the key with the default uid and the hash.
+func (s *SignGmSM2) calculateZ(key *sm2.PublicKey) ([]byte, error) {
+	return s.calculateZWithUID(key, nil)
+}
+
+// calculateZWithUID returns Z of the key with the uid and the hash, the
+// uid is 1234567812345678 when nil.
+func (s *SignGmSM2) calculateZWithUID(key *sm2.PublicKey, uid []byte) ([]byte, error) {
+	if s.Hash == 0 {
+		return CalculateSM2Z(key, uid)
+	}
+
+	if key == nil || key.X == nil || key.Y == nil {
+		return nil, ErrSM2PublicKeyInvalid
+	}
+
+	if uid == nil {
+		uid = sm2.DefaultSignerOpts.Uid
+	}
+
+	return sm2.CalculateZALegacy(key, s.Hash.New, uid)
+}
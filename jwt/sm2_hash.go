@@ -0,0 +1,22 @@
+package jwt
+
+import (
+	"crypto"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+// SM2 with the other hashes, ISO/IEC 14888-3 SM2-with-SHA256.
+// Z is hashed with the same hash, these algos are not in ComplianceGM.
+var (
+	SigningSM2SHA256 = NewSignSM2WithHash(crypto.SHA256, 32, "SM2-SHA256")
+
+	SigningMethodSM2SHA256 = jwt.NewJWT[*sm2.PrivateKey, *sm2.PublicKey](SigningSM2SHA256, jwt.JWTEncoder)
+)
+
+func init() {
+	jwt.RegisterSigningMethod(SigningSM2SHA256.Alg(), func() any {
+		return SigningSM2SHA256
+	})
+}
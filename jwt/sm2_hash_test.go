@@ -0,0 +1,216 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func Test_SigningSM2SHA256(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := &privateKey.PublicKey
+
+	msg := []byte("test-data")
+
+	signed, err := SigningSM2SHA256.Sign(msg, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	veri, err := SigningSM2SHA256.Verify(msg, signed, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !veri {
+		t.Error("Verify fail")
+	}
+
+	// the signatures of GmSM2 and SM2-SHA256 are not the same
+	if veri, _ := SigningGmSM2.Verify(msg, signed, publicKey); veri {
+		t.Error("GmSM2 Verify of SM2-SHA256 signature should fail")
+	}
+
+	gmSigned, err := SigningGmSM2.Sign(msg, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if veri, _ := SigningSM2SHA256.Verify(msg, gmSigned, publicKey); veri {
+		t.Error("SM2-SHA256 Verify of GmSM2 signature should fail")
+	}
+
+	// e = SHA256(Z || M), Z with SHA256
+	za, err := sm2.CalculateZALegacy(publicKey, sha256.New, sm2.DefaultSignerOpts.Uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := sha256.New()
+	h.Write(za)
+	h.Write(msg)
+
+	digest, err := SigningSM2SHA256.CalculateDigest(publicKey, nil, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(digest, h.Sum(nil)) {
+		t.Errorf("CalculateDigest got %x, want %x", digest, h.Sum(nil))
+	}
+
+	// GmSM2 is the SM3 digest of CalculateSM2Digest
+	uid := []byte("test-uid")
+	gmDigest, err := SigningGmSM2.CalculateDigest(publicKey, uid, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sm3Digest, err := CalculateSM2Digest(publicKey, uid, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(gmDigest, sm3Digest) {
+		t.Errorf("CalculateDigest got %x, want %x", gmDigest, sm3Digest)
+	}
+
+	if _, err := SigningSM2SHA256.CalculateDigest(nil, nil, msg); err != ErrSM2PublicKeyInvalid {
+		t.Errorf("CalculateDigest got %v, want %v", err, ErrSM2PublicKeyInvalid)
+	}
+
+	digestSigned, err := SigningSM2SHA256.SignDigest(digest, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if veri, err := SigningSM2SHA256.Verify(msg, digestSigned, publicKey); err != nil || !veri {
+		t.Errorf("Verify of SignDigest got %v, %v", veri, err)
+	}
+
+	veri, err = SigningSM2SHA256.VerifyDigest(h.Sum(nil), signed, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !veri {
+		t.Error("VerifyDigest fail")
+	}
+
+	verifier, err := SigningSM2SHA256.NewVerifier(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	veri, err = verifier.Verify(msg, signed, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !veri {
+		t.Error("SM2Verifier Verify fail")
+	}
+}
+
+func Test_SigningMethodSM2SHA256(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := map[string]string{
+		"aud": "example.com",
+		"sub": "foo",
+	}
+
+	tokenString, err := SigningMethodSM2SHA256.New().Sign(claims, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := SigningMethodSM2SHA256.New().Parse(tokenString, &privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, err := parsed.GetHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Alg != "SM2-SHA256" {
+		t.Errorf("Alg got %s, want %s", header.Alg, "SM2-SHA256")
+	}
+
+	if _, err := SigningMethodGmSM2.New().Parse(tokenString, &privateKey.PublicKey); err == nil {
+		t.Error("GmSM2 Parse of SM2-SHA256 token should return error")
+	}
+
+	signer := jwt.GetSigningMethod[*sm2.PrivateKey, *sm2.PublicKey]("SM2-SHA256")
+	if signer == nil || signer.Alg() != "SM2-SHA256" {
+		t.Error("GetSigningMethod SM2-SHA256 fail")
+	}
+
+	registry, err := NewRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !registry.Has("SM2-SHA256") {
+		t.Error("Registry Has SM2-SHA256 fail")
+	}
+}
+
+func Test_SigningSM2SHA256_Compliance(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	EnableCompliance(ComplianceGM)
+	defer DisableCompliance()
+
+	_, err = SigningSM2SHA256.Sign([]byte("test-data"), privateKey)
+	if !errors.Is(err, ErrComplianceAlgNotPermitted) {
+		t.Errorf("Sign got %v, want %v", err, ErrComplianceAlgNotPermitted)
+	}
+}
+
+func Test_SigningGmSM2_HashInvalid(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// GmSM2 is SM3 only
+	signer := NewSignSM2WithHash(crypto.SHA256, 32, "GmSM2")
+
+	if _, err := signer.Sign([]byte("test-data"), privateKey); err != ErrSignGmSM2HashInvalid {
+		t.Errorf("Sign got %v, want %v", err, ErrSignGmSM2HashInvalid)
+	}
+
+	if _, err := signer.Verify([]byte("test-data"), make([]byte, 64), &privateKey.PublicKey); err != ErrSignGmSM2HashInvalid {
+		t.Errorf("Verify got %v, want %v", err, ErrSignGmSM2HashInvalid)
+	}
+
+	if _, err := signer.NewVerifier(&privateKey.PublicKey); err != ErrSignGmSM2HashInvalid {
+		t.Errorf("NewVerifier got %v, want %v", err, ErrSignGmSM2HashInvalid)
+	}
+
+	// the hash is not linked in
+	signer = NewSignSM2WithHash(crypto.Hash(999), 32, "SM2-TEST")
+
+	if _, err := signer.Sign([]byte("test-data"), privateKey); err != ErrSignGmSM2HashInvalid {
+		t.Errorf("Sign got %v, want %v", err, ErrSignGmSM2HashInvalid)
+	}
+}
@@ -0,0 +1,161 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var ErrSM2SignatureEncodingInvalid = errors.New("go-jwt: SM2 signature encoding invalid")
+
+// sm2KeySize is the byte size of r and s of the SM2 signatures.
+const sm2KeySize = 32
+
+// DecodeLegacySM2Signature decodes the signature segment of the token and
+// returns the r || s signature. The segment is base64url r || s, or the
+// legacy encodings: hex, standard base64 or base64url of r || s or DER.
+func DecodeLegacySM2Signature(segment string) ([]byte, error) {
+	if data, err := base64.RawURLEncoding.DecodeString(segment); err == nil && len(data) == 2*sm2KeySize {
+		return data, nil
+	}
+
+	if isHexString(segment) {
+		data, err := hex.DecodeString(segment)
+		if err != nil {
+			return nil, ErrSM2SignatureEncodingInvalid
+		}
+
+		return sm2SignatureFromBytes(data, sm2KeySize)
+	}
+
+	encodings := []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	}
+
+	for _, enc := range encodings {
+		if data, err := enc.DecodeString(segment); err == nil {
+			return sm2SignatureFromBytes(data, sm2KeySize)
+		}
+	}
+
+	return nil, ErrSM2SignatureEncodingInvalid
+}
+
+// ConvertLegacySM2Token re-encodes the legacy signature of the token as
+// the base64url r || s signature. The signature is not verified.
+func ConvertLegacySM2Token(tokenString string) (string, error) {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return "", ErrTokenSegmentsInvalid
+	}
+
+	signature, err := DecodeLegacySM2Signature(parts[2])
+	if err != nil {
+		return "", err
+	}
+
+	parts[2] = base64.RawURLEncoding.EncodeToString(signature)
+
+	return strings.Join(parts, "."), nil
+}
+
+// ParseLegacySM2Token converts the legacy signature of the token and
+// parses the token with the GmSM2 signing method.
+func ParseLegacySM2Token(tokenString string, key *sm2.PublicKey) (*jwt.Token, error) {
+	converted, err := ConvertLegacySM2Token(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	return SigningMethodGmSM2.New().Parse(converted, key)
+}
+
+// normalizeLegacySignature returns the r || s signature of the signature
+// bytes passed to Verify, the bytes are r || s, DER or the hex r || s.
+// The token parser decodes the segment as base64url, so a hex segment is
+// encoded back to the hex text. The hex DER is not always the same text
+// after decoding as base64url, it is only converted by ConvertLegacySM2Token.
+func normalizeLegacySignature(signature []byte, keySize int) ([]byte, error) {
+	if len(signature) == 2*keySize {
+		return signature, nil
+	}
+
+	if rs, err := sm2SignatureFromDER(signature, keySize); err == nil {
+		return rs, nil
+	}
+
+	text := string(signature)
+	if !isHexString(text) {
+		text = base64.RawURLEncoding.EncodeToString(signature)
+	}
+
+	if len(text) == 4*keySize && isHexString(text) {
+		return hex.DecodeString(text)
+	}
+
+	return nil, ErrSM2SignatureEncodingInvalid
+}
+
+// sm2SignatureFromBytes returns the r || s signature of r || s or DER.
+func sm2SignatureFromBytes(data []byte, keySize int) ([]byte, error) {
+	if len(data) == 2*keySize {
+		return data, nil
+	}
+
+	return sm2SignatureFromDER(data, keySize)
+}
+
+// sm2SignatureFromDER returns the r || s signature of the DER signature,
+// only the DER encoding is accepted, not BER.
+func sm2SignatureFromDER(der []byte, keySize int) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+
+	rest, err := asn1.Unmarshal(der, &sig)
+	if err != nil || len(rest) != 0 {
+		return nil, ErrSM2SignatureEncodingInvalid
+	}
+
+	if sig.R.Sign() <= 0 || sig.S.Sign() <= 0 ||
+		sig.R.BitLen() > 8*keySize || sig.S.BitLen() > 8*keySize {
+		return nil, ErrSM2SignatureEncodingInvalid
+	}
+
+	// the same bytes after encoding again
+	encoded, err := asn1.Marshal(sig)
+	if err != nil || !bytes.Equal(encoded, der) {
+		return nil, ErrSM2SignatureEncodingInvalid
+	}
+
+	signature := make([]byte, 2*keySize)
+	sig.R.FillBytes(signature[:keySize])
+	sig.S.FillBytes(signature[keySize:])
+
+	return signature, nil
+}
+
+func isHexString(s string) bool {
+	if len(s) == 0 || len(s)%2 != 0 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,186 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+func legacySM2Tokens(t *testing.T) (string, map[string]string, *sm2.PublicKey) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenString, err := SigningMethodGmSM2.New().Sign(map[string]string{"sub": "foo"}, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(tokenString, ".")
+
+	rs, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := asn1.Marshal(struct {
+		R, S *big.Int
+	}{
+		new(big.Int).SetBytes(rs[:32]),
+		new(big.Int).SetBytes(rs[32:]),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingString := parts[0] + "." + parts[1] + "."
+
+	tokens := map[string]string{
+		"hex":           signingString + hex.EncodeToString(rs),
+		"hex upper":     signingString + strings.ToUpper(hex.EncodeToString(rs)),
+		"hex DER":       signingString + hex.EncodeToString(der),
+		"base64":        signingString + base64.StdEncoding.EncodeToString(rs),
+		"base64 DER":    signingString + base64.StdEncoding.EncodeToString(der),
+		"base64url DER": signingString + base64.RawURLEncoding.EncodeToString(der),
+	}
+
+	return tokenString, tokens, &privateKey.PublicKey
+}
+
+func Test_ConvertLegacySM2Token(t *testing.T) {
+	tokenString, tokens, publicKey := legacySM2Tokens(t)
+
+	for name, legacy := range tokens {
+		converted, err := ConvertLegacySM2Token(legacy)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if converted != tokenString {
+			t.Errorf("%s: ConvertLegacySM2Token got %s, want %s", name, converted, tokenString)
+		}
+
+		if _, err := ParseLegacySM2Token(legacy, publicKey); err != nil {
+			t.Errorf("%s: ParseLegacySM2Token got %v", name, err)
+		}
+	}
+
+	// the standard token is not changed
+	converted, err := ConvertLegacySM2Token(tokenString)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if converted != tokenString {
+		t.Errorf("ConvertLegacySM2Token got %s, want %s", converted, tokenString)
+	}
+
+	parts := strings.Split(tokenString, ".")
+	if _, err := ConvertLegacySM2Token(parts[0] + "." + parts[1] + ".abc"); err != ErrSM2SignatureEncodingInvalid {
+		t.Errorf("ConvertLegacySM2Token got %v, want %v", err, ErrSM2SignatureEncodingInvalid)
+	}
+
+	for _, tokenString := range []string{parts[0] + "." + parts[1], tokenString + ".abc"} {
+		if _, err := ConvertLegacySM2Token(tokenString); err != ErrTokenSegmentsInvalid {
+			t.Errorf("ConvertLegacySM2Token got %v, want %v", err, ErrTokenSegmentsInvalid)
+		}
+	}
+}
+
+func Test_SigningGmSM2_WithLegacySignatures(t *testing.T) {
+	_, tokens, publicKey := legacySM2Tokens(t)
+
+	legacy := jwt.NewJWT[*sm2.PrivateKey, *sm2.PublicKey](SigningGmSM2.WithLegacySignatures(true), jwt.JWTEncoder)
+
+	if SigningGmSM2.legacy {
+		t.Error("WithLegacySignatures changed the Signer")
+	}
+
+	// the segments decoded as base64url by the token parser
+	for _, name := range []string{"hex", "hex upper", "base64url DER"} {
+		if _, err := legacy.New().Parse(tokens[name], publicKey); err != nil {
+			t.Errorf("%s: Parse got %v", name, err)
+		}
+
+		if _, err := SigningMethodGmSM2.New().Parse(tokens[name], publicKey); err == nil {
+			t.Errorf("%s: Parse without legacy mode should return error", name)
+		}
+	}
+}
+
+func Test_SigningGmSM2_WithLegacySignatures_NewVerifier(t *testing.T) {
+	_, tokens, publicKey := legacySM2Tokens(t)
+
+	legacyVerifier, err := SigningGmSM2.WithLegacySignatures(true).NewVerifier(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := SigningGmSM2.NewVerifier(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := jwt.NewJWT[*sm2.PrivateKey, *sm2.PublicKey](legacyVerifier, jwt.JWTEncoder)
+	strict := jwt.NewJWT[*sm2.PrivateKey, *sm2.PublicKey](verifier, jwt.JWTEncoder)
+
+	for _, name := range []string{"hex", "hex upper", "base64url DER"} {
+		if _, err := legacy.New().Parse(tokens[name], nil); err != nil {
+			t.Errorf("%s: Parse got %v", name, err)
+		}
+
+		if _, err := strict.New().Parse(tokens[name], nil); err == nil {
+			t.Errorf("%s: Parse without legacy mode should return error", name)
+		}
+	}
+}
+
+func Test_sm2SignatureFromDER(t *testing.T) {
+	rs := make([]byte, 64)
+	rs[31] = 1
+	rs[63] = 2
+
+	der, err := hex.DecodeString("3006020101020102")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := sm2SignatureFromDER(der, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != string(rs) {
+		t.Errorf("sm2SignatureFromDER got %x, want %x", got, rs)
+	}
+
+	invalid := []string{
+		// BER integer with a leading zero
+		"300702020001020102",
+		// trailing data
+		"300602010102010200",
+		// zero r
+		"3006020100020102",
+		// negative s
+		"30060201010201ff",
+	}
+
+	for _, in := range invalid {
+		der, err := hex.DecodeString(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := sm2SignatureFromDER(der, 32); err != ErrSM2SignatureEncodingInvalid {
+			t.Errorf("sm2SignatureFromDER(%s) got %v, want %v", in, err, ErrSM2SignatureEncodingInvalid)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package jwt
+
+import (
+	"crypto/hmac"
+	"errors"
+	"math/big"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-cryptobin/hash/sm3"
+)
+
+var ErrDeterministicNonceKeyInvalid = errors.New("go-jwt: deterministic nonce key invalid")
+
+// DeterministicNonce derives the nonce from the private key and the digest
+// with the RFC 6979 HMAC-SM3 DRBG, the same key and message always have
+// the same signature, and the signing does not use crypto/rand. It is a
+// NonceSequence, the DRBG is continued when a nonce is invalid.
+//
+//	signer := jwt.SigningGmSM2.WithNonceSource(jwt.DeterministicNonce)
+var DeterministicNonce NonceSource = deterministicNonce{}
+
+type deterministicNonce struct{}
+
+// Nonce returns the RFC 6979 section 3.2 nonce k of the digest.
+func (d deterministicNonce) Nonce(key *sm2.PrivateKey, digest []byte) (*big.Int, error) {
+	next, err := d.Nonces(key, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return next(), nil
+}
+
+// Nonces returns the RFC 6979 section 3.2 nonces k of the digest, next
+// continues the DRBG of step h after the last nonce.
+func (deterministicNonce) Nonces(key *sm2.PrivateKey, digest []byte) (func() *big.Int, error) {
+	if key == nil || key.D == nil || key.Curve == nil {
+		return nil, ErrDeterministicNonceKeyInvalid
+	}
+
+	q := key.Curve.Params().N
+	if key.D.Sign() <= 0 || key.D.Cmp(q) >= 0 {
+		return nil, ErrDeterministicNonceKeyInvalid
+	}
+
+	qlen := q.BitLen()
+	rlen := (qlen + 7) / 8
+
+	// int2octets(x) and bits2octets(h1)
+	x := key.D.FillBytes(make([]byte, rlen))
+
+	h := bits2int(digest, qlen)
+	h.Mod(h, q)
+	h1 := h.FillBytes(make([]byte, rlen))
+
+	v := make([]byte, sm3.Size)
+	k := make([]byte, sm3.Size)
+	for i := range v {
+		v[i] = 0x01
+	}
+
+	k = hmacSM3(k, v, []byte{0x00}, x, h1)
+	v = hmacSM3(k, v)
+	k = hmacSM3(k, v, []byte{0x01}, x, h1)
+	v = hmacSM3(k, v)
+
+	// the first nonce does not update K and V
+	started := false
+
+	next := func() *big.Int {
+		for {
+			if started {
+				k = hmacSM3(k, v, []byte{0x00})
+				v = hmacSM3(k, v)
+			}
+			started = true
+
+			var t []byte
+			for len(t) < rlen {
+				v = hmacSM3(k, v)
+				t = append(t, v...)
+			}
+
+			nonce := bits2int(t, qlen)
+			if nonce.Sign() > 0 && nonce.Cmp(q) < 0 {
+				return nonce
+			}
+		}
+	}
+
+	return next, nil
+}
+
+// bits2int returns the leftmost qlen bits of b as an integer.
+func bits2int(b []byte, qlen int) *big.Int {
+	n := new(big.Int).SetBytes(b)
+	if blen := len(b) * 8; blen > qlen {
+		n.Rsh(n, uint(blen-qlen))
+	}
+
+	return n
+}
+
+func hmacSM3(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(sm3.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,204 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+)
+
+func Test_DeterministicNonce(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := SigningGmSM2.WithNonceSource(DeterministicNonce)
+
+	signed1, err := h.Sign([]byte("test-data"), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed2, err := h.SignReader(bytes.NewReader([]byte("test-data")), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(signed1, signed2) {
+		t.Error("the signatures of the same message are not the same")
+	}
+
+	signed3, err := h.Sign([]byte("test-data2"), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(signed1, signed3) {
+		t.Error("the signatures of other messages are the same")
+	}
+
+	veri, err := SigningGmSM2.Verify([]byte("test-data"), signed1, &privateKey.PublicKey)
+	if err != nil || !veri {
+		t.Errorf("Verify got %v, %v", veri, err)
+	}
+
+	if _, err := DeterministicNonce.Nonce(nil, make([]byte, 32)); err != ErrDeterministicNonceKeyInvalid {
+		t.Errorf("Nonce got %v, want %v", err, ErrDeterministicNonceKeyInvalid)
+	}
+}
+
+type testNonceSequence []*big.Int
+
+func (n testNonceSequence) Nonce(key *sm2.PrivateKey, digest []byte) (*big.Int, error) {
+	return n[0], nil
+}
+
+func (n testNonceSequence) Nonces(key *sm2.PrivateKey, digest []byte) (func() *big.Int, error) {
+	i := 0
+	return func() *big.Int {
+		k := n[i%len(n)]
+		i++
+		return k
+	}, nil
+}
+
+func Test_SigningGmSM2_NonceSequence(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	curve := privateKey.Curve
+	N := curve.Params().N
+
+	k1 := big.NewInt(12345)
+	k2 := big.NewInt(67890)
+
+	// the digest e = n - k1 - x1 makes r + k1 = n
+	x1, _ := curve.ScalarBaseMult(k1.Bytes())
+	e := new(big.Int).Sub(N, k1)
+	e.Sub(e, x1)
+	e.Mod(e, N)
+	digest := e.FillBytes(make([]byte, 32))
+
+	// the fixed nonce fails
+	fixed := SigningGmSM2.WithNonceSource(NonceSourceFunc(func(*sm2.PrivateKey, []byte) (*big.Int, error) {
+		return k1, nil
+	}))
+	if _, err := fixed.SignDigest(digest, privateKey); err != ErrSignGmSM2NonceInvalid {
+		t.Errorf("SignDigest got %v, want %v", err, ErrSignGmSM2NonceInvalid)
+	}
+
+	// the sequence signs with the next nonce
+	signed, err := SigningGmSM2.WithNonceSource(testNonceSequence{k1, k2}).SignDigest(digest, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := SigningGmSM2.WithNonceSource(testNonceSequence{k2}).SignDigest(digest, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(signed, want) {
+		t.Errorf("SignDigest got %x, want %x", signed, want)
+	}
+
+	veri, err := SigningGmSM2.VerifyDigest(digest, signed, &privateKey.PublicKey)
+	if err != nil || !veri {
+		t.Errorf("VerifyDigest got %v, %v", veri, err)
+	}
+
+	// no valid nonce
+	if _, err := SigningGmSM2.WithNonceSource(testNonceSequence{k1}).SignDigest(digest, privateKey); err != ErrSignGmSM2NonceInvalid {
+		t.Errorf("SignDigest got %v, want %v", err, ErrSignGmSM2NonceInvalid)
+	}
+}
+
+func Test_DeterministicNonce_Nonces(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := make([]byte, 32)
+	digest[0] = 0x01
+
+	seq, ok := DeterministicNonce.(NonceSequence)
+	if !ok {
+		t.Fatal("DeterministicNonce is not a NonceSequence")
+	}
+
+	next, err := seq.Nonces(privateKey, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := DeterministicNonce.Nonce(privateKey, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := next(), next()
+	if first.Cmp(k) != 0 {
+		t.Errorf("Nonces first got %x, want %x", first, k)
+	}
+
+	N := privateKey.Curve.Params().N
+	if second.Cmp(first) == 0 || second.Sign() <= 0 || second.Cmp(N) >= 0 {
+		t.Errorf("Nonces second got %x", second)
+	}
+
+	// the sequence is the same for the same key and digest
+	next2, _ := seq.Nonces(privateKey, digest)
+	if next2(); next2().Cmp(second) != 0 {
+		t.Error("Nonces second is not deterministic")
+	}
+}
+
+func Test_SigningGmSM2_NonceRange(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	N := privateKey.Curve.Params().N
+	digest := make([]byte, 32)
+	digest[0] = 0x01
+
+	invalid := map[string]*big.Int{
+		"zero":     big.NewInt(0),
+		"negative": big.NewInt(-1),
+		"n":        new(big.Int).Set(N),
+		"over n":   new(big.Int).Add(N, big.NewInt(1)),
+	}
+
+	valid := big.NewInt(12345)
+
+	for name, k := range invalid {
+		fixed := SigningGmSM2.WithNonceSource(NonceSourceFunc(func(*sm2.PrivateKey, []byte) (*big.Int, error) {
+			return k, nil
+		}))
+		if _, err := fixed.SignDigest(digest, privateKey); err != ErrSignGmSM2NonceInvalid {
+			t.Errorf("%s: SignDigest got %v, want %v", name, err, ErrSignGmSM2NonceInvalid)
+		}
+
+		// the sequence takes the next nonce
+		signed, err := SigningGmSM2.WithNonceSource(testNonceSequence{k, valid}).SignDigest(digest, privateKey)
+		if err != nil {
+			t.Fatalf("%s: SignDigest got %v", name, err)
+		}
+
+		want, err := SigningGmSM2.WithNonceSource(testNonceSequence{valid}).SignDigest(digest, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(signed, want) {
+			t.Errorf("%s: SignDigest got %x, want %x", name, signed, want)
+		}
+	}
+}
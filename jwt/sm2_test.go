@@ -1,7 +1,11 @@
 package jwt
 
 import (
+	"bytes"
+	"crypto/elliptic"
 	"crypto/rand"
+	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/deatil/go-cryptobin/gm/sm2"
@@ -240,3 +244,369 @@ func Test_SigningMethodGmSM2_Parse(t *testing.T) {
 	}
 
 }
+
+func Test_SigningGmSM2_SignReader(t *testing.T) {
+	h := SigningGmSM2
+
+	msg := bytes.Repeat([]byte("test-data"), 10000)
+
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := &privateKey.PublicKey
+
+	signed, err := h.SignReader(bytes.NewReader(msg), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	veri, err := h.Verify(msg, signed, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !veri {
+		t.Error("Verify fail")
+	}
+
+	signed2, err := h.Sign(msg, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	veri, err = h.VerifyReader(bytes.NewReader(msg), signed2, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !veri {
+		t.Error("VerifyReader fail")
+	}
+
+	_, err = h.VerifyReader(bytes.NewReader(msg[1:]), signed2, publicKey)
+	if err != ErrSignGmSM2VerifyFail {
+		t.Errorf("VerifyReader got %v, want %v", err, ErrSignGmSM2VerifyFail)
+	}
+}
+
+func Test_SigningGmSM2_NewVerifier(t *testing.T) {
+	h := SigningGmSM2
+
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := &privateKey.PublicKey
+
+	v, err := h.NewVerifier(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Alg() != "GmSM2" {
+		t.Errorf("Alg got %s, want %s", v.Alg(), "GmSM2")
+	}
+
+	for i := 0; i < 20; i++ {
+		msg := []byte(fmt.Sprintf("test-data-%d", i))
+
+		signed, err := h.Sign(msg, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		veri, err := v.Verify(msg, signed, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !veri {
+			t.Error("Verify fail")
+		}
+
+		signed[10] ^= 0x01
+		if _, err = v.Verify(msg, signed, publicKey); err != ErrSignGmSM2VerifyFail {
+			t.Errorf("Verify got %v, want %v", err, ErrSignGmSM2VerifyFail)
+		}
+	}
+
+	// other keys fall back to SignGmSM2
+	otherKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := h.Sign([]byte("test-data"), otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	veri, err := v.Verify([]byte("test-data"), signed, &otherKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !veri {
+		t.Error("Verify fail")
+	}
+
+	if _, err = v.Verify([]byte("test-data"), signed, nil); err != ErrSignGmSM2VerifyFail {
+		t.Errorf("Verify got %v, want %v", err, ErrSignGmSM2VerifyFail)
+	}
+
+	if _, err = h.NewVerifier(nil); err != ErrSM2VerifierKeyInvalid {
+		t.Errorf("NewVerifier got %v, want %v", err, ErrSM2VerifierKeyInvalid)
+	}
+}
+
+func Test_SigningGmSM2_NewVerifier_Parse(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := SigningGmSM2.NewVerifier(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := map[string]string{
+		"aud": "example.com",
+	}
+
+	tokenString, err := SigningMethodGmSM2.New().Sign(claims, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := jwt.NewJWT[*sm2.PrivateKey, *sm2.PublicKey](v, jwt.JWTEncoder)
+	parsed, err := p.Parse(tokenString, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims2, err := parsed.GetClaims()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims2["aud"].(string) != claims["aud"] {
+		t.Errorf("GetClaims aud got %s, want %s", claims2["aud"].(string), claims["aud"])
+	}
+}
+
+func Test_ValidateSM2PublicKey(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := &privateKey.PublicKey
+	if err := ValidateSM2PublicKey(publicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	p := sm2.P256().Params().P
+
+	tests := []struct {
+		name string
+		key  *sm2.PublicKey
+	}{
+		{"nil", nil},
+		{"nil X", &sm2.PublicKey{Curve: sm2.P256(), Y: publicKey.Y}},
+		{"infinity", &sm2.PublicKey{Curve: sm2.P256(), X: new(big.Int), Y: new(big.Int)}},
+		{"off curve", &sm2.PublicKey{Curve: sm2.P256(), X: publicKey.X, Y: publicKey.X}},
+		{"X out of range", &sm2.PublicKey{Curve: sm2.P256(), X: new(big.Int).Add(publicKey.X, p), Y: publicKey.Y}},
+		{"negative Y", &sm2.PublicKey{Curve: sm2.P256(), X: publicKey.X, Y: new(big.Int).Neg(publicKey.Y)}},
+		{"wrong curve", &sm2.PublicKey{Curve: elliptic.P256(), X: publicKey.X, Y: publicKey.Y}},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateSM2PublicKey(tt.key); err != ErrSM2PublicKeyInvalid {
+			t.Errorf("%s: ValidateSM2PublicKey got %v, want %v", tt.name, err, ErrSM2PublicKeyInvalid)
+		}
+	}
+
+	// the off curve point is rejected when parsing
+	der, err := sm2.MarshalPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der[len(der)-1] ^= 0x01
+	if _, err := ParseSM2PublicKeyFromDer(der); err == nil {
+		t.Error("ParseSM2PublicKeyFromDer should return error")
+	}
+}
+
+func Test_SigningGmSM2_WithKeyValidation(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := []byte("test-data")
+
+	signed, err := SigningGmSM2.Sign(msg, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := SigningGmSM2.WithKeyValidation(true)
+	if SigningGmSM2.validateKey {
+		t.Error("WithKeyValidation changed the Signer")
+	}
+
+	ok, err := h.Verify(msg, signed, &privateKey.PublicKey)
+	if err != nil || !ok {
+		t.Fatalf("Verify got %v, %v", ok, err)
+	}
+
+	invalid := &sm2.PublicKey{
+		Curve: sm2.P256(),
+		X:     privateKey.X,
+		Y:     privateKey.X,
+	}
+
+	if _, err := h.Verify(msg, signed, invalid); err != ErrSM2PublicKeyInvalid {
+		t.Errorf("Verify got %v, want %v", err, ErrSM2PublicKeyInvalid)
+	}
+
+	if _, err := h.VerifyReader(bytes.NewReader(msg), signed, invalid); err != ErrSM2PublicKeyInvalid {
+		t.Errorf("VerifyReader got %v, want %v", err, ErrSM2PublicKeyInvalid)
+	}
+}
+
+func Test_CheckSM2KeyPair(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKey2, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckSM2KeyPair(privateKey, &privateKey.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckSM2KeyPair(privateKey, &privateKey2.PublicKey); err != ErrSM2KeyPairMismatch {
+		t.Errorf("CheckSM2KeyPair got %v, want %v", err, ErrSM2KeyPairMismatch)
+	}
+
+	// the stored public key of the private key is not trusted
+	forged := *privateKey2
+	forged.PublicKey = privateKey.PublicKey
+	if err := CheckSM2KeyPair(&forged, &privateKey.PublicKey); err != ErrSM2KeyPairMismatch {
+		t.Errorf("CheckSM2KeyPair got %v, want %v", err, ErrSM2KeyPairMismatch)
+	}
+
+	if err := CheckSM2KeyPair(nil, &privateKey.PublicKey); err != ErrSM2PrivateKeyInvalid {
+		t.Errorf("CheckSM2KeyPair got %v, want %v", err, ErrSM2PrivateKeyInvalid)
+	}
+}
+
+func Benchmark_SigningGmSM2_Verify(b *testing.B) {
+	privateKey, _ := sm2.GenerateKey(rand.Reader)
+	publicKey := &privateKey.PublicKey
+
+	msg := []byte("test-data")
+	signed, _ := SigningGmSM2.Sign(msg, privateKey)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		SigningGmSM2.Verify(msg, signed, publicKey)
+	}
+}
+
+func Benchmark_SM2Verifier_Verify(b *testing.B) {
+	privateKey, _ := sm2.GenerateKey(rand.Reader)
+	publicKey := &privateKey.PublicKey
+
+	msg := []byte("test-data")
+	signed, _ := SigningGmSM2.Sign(msg, privateKey)
+
+	v, _ := SigningGmSM2.NewVerifier(publicKey)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		v.Verify(msg, signed, nil)
+	}
+}
+
+func Benchmark_SigningGmSM2_NewVerifier(b *testing.B) {
+	privateKey, _ := sm2.GenerateKey(rand.Reader)
+	publicKey := &privateKey.PublicKey
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		SigningGmSM2.NewVerifier(publicKey)
+	}
+}
+
+// benchmarkClaimSizes is the claim counts of the token benchmarks.
+var benchmarkClaimSizes = []int{2, 20, 200}
+
+func benchmarkClaims(n int) map[string]string {
+	claims := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		claims[fmt.Sprintf("claim-%d", i)] = fmt.Sprintf("value-%d", i)
+	}
+
+	return claims
+}
+
+func Benchmark_SigningGmSM2_Sign(b *testing.B) {
+	privateKey, _ := sm2.GenerateKey(rand.Reader)
+
+	msg := []byte("test-data")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		SigningGmSM2.Sign(msg, privateKey)
+	}
+}
+
+func Benchmark_SigningMethodGmSM2_Sign(b *testing.B) {
+	privateKey, _ := sm2.GenerateKey(rand.Reader)
+
+	for _, size := range benchmarkClaimSizes {
+		claims := benchmarkClaims(size)
+
+		b.Run(fmt.Sprintf("claims-%d", size), func(b *testing.B) {
+			s := SigningMethodGmSM2.New()
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				s.Sign(claims, privateKey)
+			}
+		})
+	}
+}
+
+func Benchmark_SigningMethodGmSM2_Parse(b *testing.B) {
+	privateKey, _ := sm2.GenerateKey(rand.Reader)
+	publicKey := &privateKey.PublicKey
+
+	for _, size := range benchmarkClaimSizes {
+		tokenString, _ := SigningMethodGmSM2.New().Sign(benchmarkClaims(size), privateKey)
+
+		b.Run(fmt.Sprintf("claims-%d", size), func(b *testing.B) {
+			p := SigningMethodGmSM2.New()
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				p.Parse(tokenString, publicKey)
+			}
+		})
+	}
+}
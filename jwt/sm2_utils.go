@@ -1,7 +1,18 @@
 package jwt
 
 import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+
 	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-cryptobin/hash/sm3"
+)
+
+var (
+	ErrSM2PublicKeyInvalid  = errors.New("go-jwt: SM2 public key invalid")
+	ErrSM2KeyPairMismatch   = errors.New("go-jwt: SM2 private key not match the public key")
+	ErrSM2PrivateKeyInvalid = errors.New("go-jwt: SM2 private key invalid")
 )
 
 // ParseSM2PrivateKeyFromDer parses a PEM encoded PKCS1 or PKCS8 private key
@@ -19,10 +30,107 @@ func ParseSM2PrivateKeyFromDer(der []byte) (*sm2.PrivateKey, error) {
 
 // ParseSM2PublicKeyFromDer parses a PEM encoded PKCS8 public key
 func ParseSM2PublicKeyFromDer(der []byte) (*sm2.PublicKey, error) {
+	// sm2.ParsePublicKey panics when the curve parameters are not an OID
+	var spki struct {
+		Algo      pkix.AlgorithmIdentifier
+		BitString asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, err
+	}
+
+	var namedCurveOID asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(spki.Algo.Parameters.FullBytes, &namedCurveOID); err != nil {
+		return nil, ErrSM2PublicKeyInvalid
+	}
+
 	pkey, err := sm2.ParsePublicKey(der)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := ValidateSM2PublicKey(pkey); err != nil {
+		return nil, err
+	}
+
 	return pkey, nil
 }
+
+// ValidateSM2PublicKey checks the key is a point of the SM2 curve, with
+// the coordinates in range, not the infinity and of the order n.
+func ValidateSM2PublicKey(key *sm2.PublicKey) error {
+	if key == nil || key.X == nil || key.Y == nil || key.Curve != sm2.P256() {
+		return ErrSM2PublicKeyInvalid
+	}
+
+	params := key.Curve.Params()
+	if key.X.Sign() < 0 || key.X.Cmp(params.P) >= 0 || key.Y.Sign() < 0 || key.Y.Cmp(params.P) >= 0 {
+		return ErrSM2PublicKeyInvalid
+	}
+
+	// (0, 0) is the infinity of crypto/elliptic
+	if key.X.Sign() == 0 && key.Y.Sign() == 0 {
+		return ErrSM2PublicKeyInvalid
+	}
+
+	if !key.Curve.IsOnCurve(key.X, key.Y) {
+		return ErrSM2PublicKeyInvalid
+	}
+
+	// n * Q is the infinity
+	x, y := key.Curve.ScalarMult(key.X, key.Y, params.N.Bytes())
+	if x.Sign() != 0 || y.Sign() != 0 {
+		return ErrSM2PublicKeyInvalid
+	}
+
+	return nil
+}
+
+// CheckSM2KeyPair checks the private key is the key of the public key.
+func CheckSM2KeyPair(priv *sm2.PrivateKey, pub *sm2.PublicKey) error {
+	if err := ValidateSM2PublicKey(pub); err != nil {
+		return err
+	}
+
+	if priv == nil || priv.Curve != sm2.P256() || priv.D == nil || priv.D.Sign() <= 0 || priv.D.Cmp(priv.Curve.Params().N) >= 0 {
+		return ErrSM2PrivateKeyInvalid
+	}
+
+	// the public key of d, the stored public key of priv is not trusted
+	x, y := priv.Curve.ScalarBaseMult(priv.D.Bytes())
+	if x.Cmp(pub.X) != 0 || y.Cmp(pub.Y) != 0 {
+		return ErrSM2KeyPairMismatch
+	}
+
+	return nil
+}
+
+// CalculateSM2Z returns Z = SM3(ENTL || ID || a || b || xG || yG || xA || yA)
+// of the public key, the uid is 1234567812345678 when nil.
+func CalculateSM2Z(key *sm2.PublicKey, uid []byte) ([]byte, error) {
+	if key == nil || key.X == nil || key.Y == nil {
+		return nil, ErrSM2PublicKeyInvalid
+	}
+
+	if uid == nil {
+		uid = sm2.DefaultSignerOpts.Uid
+	}
+
+	return sm2.CalculateZA(key, uid)
+}
+
+// CalculateSM2Digest returns the digest e = SM3(Z || M) to sign and verify
+// with SignDigest and VerifyDigest of GmSM2, the uid is 1234567812345678
+// when nil. The SM2 signers of other hashes use their CalculateDigest.
+func CalculateSM2Digest(key *sm2.PublicKey, uid []byte, msg []byte) ([]byte, error) {
+	za, err := CalculateSM2Z(key, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := sm3.New()
+	hasher.Write(za)
+	hasher.Write(msg)
+
+	return hasher.Sum(nil), nil
+}
@@ -0,0 +1,216 @@
+package jwt
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-cryptobin/gm/sm2/sm2curve"
+)
+
+var ErrSM2VerifierKeyInvalid = errors.New("go-jwt: SM2Verifier public key invalid")
+
+// sm2 verifier table windows, 4 bits a window for 256 bits scalar.
+const sm2VerifierWindows = 64
+
+// SM2Verifier verifies GmSM2 signatures with one public key.
+// The Z value and the point tables of the public key are computed
+// once, so every verify only hashes the message and adds table points.
+// It implements the Signer, and falls back to SignGmSM2 for other keys.
+type SM2Verifier struct {
+	signer *SignGmSM2
+	key    *sm2.PublicKey
+	za     []byte
+
+	// table[j][i-1] is i * 16^j * key
+	table [sm2VerifierWindows][15]*sm2curve.Point
+}
+
+// NewVerifier returns the SM2Verifier for the public key. The key is
+// always validated, and the legacy signatures are accepted when the
+// signer has WithLegacySignatures.
+func (s *SignGmSM2) NewVerifier(key *sm2.PublicKey) (*SM2Verifier, error) {
+	if ValidateSM2PublicKey(key) != nil {
+		return nil, ErrSM2VerifierKeyInvalid
+	}
+
+	if err := s.checkHash(); err != nil {
+		return nil, err
+	}
+
+	za, err := s.calculateZ(key)
+	if err != nil {
+		return nil, err
+	}
+
+	point, err := sm2curve.NewPoint().SetBytes(sm2.PublicKeyTo(key))
+	if err != nil {
+		return nil, ErrSM2VerifierKeyInvalid
+	}
+
+	v := &SM2Verifier{
+		signer: s,
+		key:    key,
+		za:     za,
+	}
+
+	base := point
+	for j := 0; j < sm2VerifierWindows; j++ {
+		v.table[j][0] = sm2curve.NewPoint().Set(base)
+		for i := 1; i < 15; i++ {
+			v.table[j][i] = sm2curve.NewPoint().Add(v.table[j][i-1], base)
+		}
+
+		base = sm2curve.NewPoint().Double(v.table[j][7])
+	}
+
+	return v, nil
+}
+
+// Signer algo name.
+func (v *SM2Verifier) Alg() string {
+	return v.signer.Alg()
+}
+
+// Signer signed bytes length.
+func (v *SM2Verifier) SignLength() int {
+	return v.signer.SignLength()
+}
+
+// PublicKey returns the public key of the verifier.
+func (v *SM2Verifier) PublicKey() *sm2.PublicKey {
+	return v.key
+}
+
+// Sign implements token signing for the Signer.
+func (v *SM2Verifier) Sign(msg []byte, key *sm2.PrivateKey) ([]byte, error) {
+	return v.signer.Sign(msg, key)
+}
+
+// Verify implements token verification for the Signer.
+// The precomputed tables are used when key is nil or the verifier key.
+func (v *SM2Verifier) Verify(msg []byte, signature []byte, key *sm2.PublicKey) (bool, error) {
+	if key != nil && !v.key.Equal(key) {
+		return v.signer.Verify(msg, signature, key)
+	}
+
+	if err := CheckCompliance(v.signer.Name); err != nil {
+		return false, err
+	}
+
+	signature, err := v.checkSignature(signature)
+	if err != nil {
+		return false, err
+	}
+
+	hasher := v.signer.newHash()
+	hasher.Write(v.za)
+	hasher.Write(msg)
+
+	if !v.verifyDigest(hasher.Sum(nil), signature) {
+		return false, ErrSignGmSM2VerifyFail
+	}
+
+	return true, nil
+}
+
+// VerifyDigest verifies the signature of the digest e = SM3(Z || M)
+// with the precomputed tables.
+func (v *SM2Verifier) VerifyDigest(digest []byte, signature []byte) (bool, error) {
+	if err := CheckCompliance(v.signer.Name); err != nil {
+		return false, err
+	}
+
+	if len(digest) != v.signer.newHash().Size() {
+		return false, ErrSignGmSM2DigestInvalid
+	}
+
+	signature, err := v.checkSignature(signature)
+	if err != nil {
+		return false, err
+	}
+
+	if !v.verifyDigest(digest, signature) {
+		return false, ErrSignGmSM2VerifyFail
+	}
+
+	return true, nil
+}
+
+// checkSignature returns the r || s signature, the legacy encodings are
+// normalized in the legacy mode of the signer.
+func (v *SM2Verifier) checkSignature(signature []byte) ([]byte, error) {
+	if v.signer.legacy {
+		normalized, err := normalizeLegacySignature(signature, v.signer.KeySize)
+		if err != nil {
+			return nil, err
+		}
+
+		signature = normalized
+	}
+
+	if len(signature) != v.signer.SignLength() {
+		return nil, ErrSignGmSM2SignLengthInvalid
+	}
+
+	return signature, nil
+}
+
+func (v *SM2Verifier) verifyDigest(digest []byte, signature []byte) bool {
+	N := v.key.Curve.Params().N
+
+	rr, ss, err := sm2.UnmarshalSignatureBytes(v.key.Curve, signature)
+	if err != nil {
+		return false
+	}
+
+	if rr.Sign() <= 0 || ss.Sign() <= 0 || rr.Cmp(N) >= 0 || ss.Cmp(N) >= 0 {
+		return false
+	}
+
+	t := new(big.Int).Add(rr, ss)
+	t.Mod(t, N)
+	if t.Sign() == 0 {
+		return false
+	}
+
+	var sBytes, tBytes [32]byte
+	ss.FillBytes(sBytes[:])
+	t.FillBytes(tBytes[:])
+
+	point, err := sm2curve.NewPoint().ScalarBaseMult(sBytes[:])
+	if err != nil {
+		return false
+	}
+
+	point.Add(point, v.scalarMult(&tBytes))
+
+	x, err := point.BytesX()
+	if err != nil {
+		return false
+	}
+
+	e := new(big.Int).SetBytes(digest)
+	e.Add(e, new(big.Int).SetBytes(x))
+	e.Mod(e, N)
+
+	return e.Cmp(rr) == 0
+}
+
+// scalarMult returns scalar * key with the precomputed tables.
+func (v *SM2Verifier) scalarMult(scalar *[32]byte) *sm2curve.Point {
+	acc := sm2curve.NewPoint()
+
+	for j := 0; j < sm2VerifierWindows; j++ {
+		b := scalar[31-j/2]
+		if j%2 == 1 {
+			b >>= 4
+		}
+
+		if n := b & 0x0f; n != 0 {
+			acc.Add(acc, v.table[j][n-1])
+		}
+	}
+
+	return acc
+}
@@ -1,12 +1,17 @@
 package jwt
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"errors"
+	"io"
+
 	"github.com/deatil/go-cryptobin/hash/sm3"
 	"github.com/deatil/go-jwt/jwt"
 )
 
 var (
-	SigningHSM3 = jwt.NewSignHmac(sm3.New, "HSM3")
+	SigningHSM3 = NewSignHSM3("HSM3")
 
 	SigningMethodHSM3 = jwt.NewJWT[[]byte, []byte](SigningHSM3, jwt.JWTEncoder)
 )
@@ -16,3 +21,69 @@ func init() {
 		return SigningHSM3
 	})
 }
+
+var ErrSignHSM3VerifyFail = errors.New("go-jwt: SignHSM3 Verify fail")
+
+// SignHSM3 implements the HMAC-SM3 signing method, it checks the
+// compliance profile and hashes the message read from r incrementally.
+type SignHSM3 struct {
+	Name string
+}
+
+func NewSignHSM3(name string) *SignHSM3 {
+	return &SignHSM3{
+		Name: name,
+	}
+}
+
+// Signer algo name.
+func (s *SignHSM3) Alg() string {
+	return s.Name
+}
+
+// Signer signed bytes length.
+func (s *SignHSM3) SignLength() int {
+	return sm3.Size
+}
+
+// Sign implements token signing for the Signer.
+func (s *SignHSM3) Sign(msg []byte, key []byte) ([]byte, error) {
+	return s.SignReader(bytes.NewReader(msg), key)
+}
+
+// Verify implements token verification for the Signer.
+func (s *SignHSM3) Verify(msg []byte, signature []byte, key []byte) (bool, error) {
+	return s.VerifyReader(bytes.NewReader(msg), signature, key)
+}
+
+// SignReader implements signing for the message read from r.
+func (s *SignHSM3) SignReader(r io.Reader, key []byte) ([]byte, error) {
+	if err := CheckCompliance(s.Name); err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sm3.New, key)
+	if _, err := io.Copy(mac, r); err != nil {
+		return nil, err
+	}
+
+	return mac.Sum(nil), nil
+}
+
+// VerifyReader implements verification for the message read from r.
+func (s *SignHSM3) VerifyReader(r io.Reader, signature []byte, key []byte) (bool, error) {
+	if err := CheckCompliance(s.Name); err != nil {
+		return false, err
+	}
+
+	mac := hmac.New(sm3.New, key)
+	if _, err := io.Copy(mac, r); err != nil {
+		return false, err
+	}
+
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return false, ErrSignHSM3VerifyFail
+	}
+
+	return true, nil
+}
@@ -45,6 +45,25 @@ func Test_SigningHSM3(t *testing.T) {
 
 }
 
+func Test_SigningHSM3_Compliance(t *testing.T) {
+	key := []byte("test-key")
+
+	EnableCompliance(NewComplianceProfile("SM2", "GmSM2"))
+	defer DisableCompliance()
+
+	if _, err := SigningHSM3.Sign([]byte("test-data"), key); err != ErrComplianceAlgNotPermitted {
+		t.Errorf("Sign got %v, want %v", err, ErrComplianceAlgNotPermitted)
+	}
+
+	if _, err := SigningHSM3.Verify([]byte("test-data"), make([]byte, 32), key); err != ErrComplianceAlgNotPermitted {
+		t.Errorf("Verify got %v, want %v", err, ErrComplianceAlgNotPermitted)
+	}
+
+	if _, err := SigningMethodHSM3.New().Sign(map[string]string{"sub": "foo"}, key); err != ErrComplianceAlgNotPermitted {
+		t.Errorf("SigningMethodHSM3 Sign got %v, want %v", err, ErrComplianceAlgNotPermitted)
+	}
+}
+
 func Test_SigningMethodHSM3(t *testing.T) {
 	claims := map[string]string{
 		"aud": "example.com",
@@ -137,3 +156,63 @@ func Test_SigningMethodHSM3_Parse(t *testing.T) {
 	}
 
 }
+
+func Benchmark_SigningHSM3_Sign(b *testing.B) {
+	msg := []byte("test-data")
+	key := []byte("test-key")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		SigningHSM3.Sign(msg, key)
+	}
+}
+
+func Benchmark_SigningHSM3_Verify(b *testing.B) {
+	msg := []byte("test-data")
+	key := []byte("test-key")
+
+	signed, _ := SigningHSM3.Sign(msg, key)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		SigningHSM3.Verify(msg, signed, key)
+	}
+}
+
+func Benchmark_SigningMethodHSM3_Sign(b *testing.B) {
+	key := []byte("test-key")
+
+	for _, size := range benchmarkClaimSizes {
+		claims := benchmarkClaims(size)
+
+		b.Run(fmt.Sprintf("claims-%d", size), func(b *testing.B) {
+			s := SigningMethodHSM3.New()
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				s.Sign(claims, key)
+			}
+		})
+	}
+}
+
+func Benchmark_SigningMethodHSM3_Parse(b *testing.B) {
+	key := []byte("test-key")
+
+	for _, size := range benchmarkClaimSizes {
+		tokenString, _ := SigningMethodHSM3.New().Sign(benchmarkClaims(size), key)
+
+		b.Run(fmt.Sprintf("claims-%d", size), func(b *testing.B) {
+			p := SigningMethodHSM3.New()
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				p.Parse(tokenString, key)
+			}
+		})
+	}
+}
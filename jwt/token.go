@@ -0,0 +1,60 @@
+package jwt
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/deatil/go-jwt/jwt"
+)
+
+var ErrTokenSegmentsInvalid = errors.New("go-jwt: token segments invalid")
+
+// CheckTokenSegments checks the token has three segments encoded by the
+// encoder, jwt.JWTEncoder when not set. The go-jwt parser reads the first
+// three segments only and ignores the base64 errors, it verifies a token
+// with other segments appended, or with the new lines or the bits after
+// the data of a segment changed.
+func CheckTokenSegments(tokenString string, encoder ...jwt.IEncoder) error {
+	var useEncoder jwt.IEncoder
+	if len(encoder) > 0 && encoder[0] != nil {
+		useEncoder = encoder[0]
+	} else {
+		useEncoder = jwt.JWTEncoder
+	}
+
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return ErrTokenSegmentsInvalid
+	}
+
+	// the decoder ignores the new line characters
+	for _, part := range parts {
+		data, err := useEncoder.Base64URLDecode(part)
+		if err != nil {
+			return ErrTokenSegmentsInvalid
+		}
+
+		encoded, err := useEncoder.Base64URLEncode(data)
+		if err != nil || encoded != part {
+			return ErrTokenSegmentsInvalid
+		}
+	}
+
+	return nil
+}
+
+// ParseStrict checks the token segments and parses the token with the
+// method. The encoder of the method can not be read, a method with its
+// own encoder passes it as the encoder, the token is parsed with it.
+func ParseStrict[S any, V any](method jwt.JWT[S, V], tokenString string, verifyKey V, encoder ...jwt.IEncoder) (*jwt.Token, error) {
+	if err := CheckTokenSegments(tokenString, encoder...); err != nil {
+		return nil, err
+	}
+
+	p := method.New()
+	if len(encoder) > 0 && encoder[0] != nil {
+		p.WithEncoder(encoder[0])
+	}
+
+	return p.Parse(tokenString, verifyKey)
+}
@@ -0,0 +1,132 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+)
+
+// paddedEncoder is the encoder of the padded base64url segments.
+type paddedEncoder struct {
+	jwt.JoseEncoder
+}
+
+func (e *paddedEncoder) Base64URLEncode(data []byte) (string, error) {
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
+func (e *paddedEncoder) Base64URLDecode(data string) ([]byte, error) {
+	return base64.URLEncoding.DecodeString(data)
+}
+
+func Test_CheckTokenSegments(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenString, err := SigningMethodGmSM2.New().Sign(map[string]string{"sub": "foo"}, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckTokenSegments(tokenString); err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(tokenString, ".")
+
+	// the go-jwt parser verifies the tokens
+	invalid := []string{
+		tokenString + ".x",
+		tokenString + "..",
+		// the header with a byte not decoded
+		parts[0] + "0." + parts[1] + "." + parts[2],
+		parts[0] + "." + parts[1][:4] + "\r\n" + parts[1][4:] + "." + parts[2],
+	}
+
+	for _, in := range invalid {
+		if _, err := SigningMethodGmSM2.New().Parse(in, &privateKey.PublicKey); err != nil {
+			t.Errorf("go-jwt Parse(%q) got %v", in, err)
+		}
+
+		if err := CheckTokenSegments(in); err != ErrTokenSegmentsInvalid {
+			t.Errorf("CheckTokenSegments(%q) got %v, want %v", in, err, ErrTokenSegmentsInvalid)
+		}
+
+		parser := NewAllowlistParser[*sm2.PrivateKey, *sm2.PublicKey]("GmSM2")
+		if _, err := parser.Parse(in, &privateKey.PublicKey); err != ErrTokenSegmentsInvalid {
+			t.Errorf("AllowlistParser Parse(%q) got %v, want %v", in, err, ErrTokenSegmentsInvalid)
+		}
+	}
+
+	if err := CheckTokenSegments("a.b"); err != ErrTokenSegmentsInvalid {
+		t.Errorf("CheckTokenSegments got %v, want %v", err, ErrTokenSegmentsInvalid)
+	}
+}
+
+func Test_CheckTokenSegments_Encoder(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := &privateKey.PublicKey
+	encoder := &paddedEncoder{}
+
+	tokenString, err := SigningMethodGmSM2.New().WithEncoder(encoder).Sign(map[string]string{"sub": "foo"}, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(tokenString, "=") {
+		t.Fatalf("the token %s is not padded", tokenString)
+	}
+
+	if err := CheckTokenSegments(tokenString); err != ErrTokenSegmentsInvalid {
+		t.Errorf("CheckTokenSegments got %v, want %v", err, ErrTokenSegmentsInvalid)
+	}
+
+	if err := CheckTokenSegments(tokenString, encoder); err != nil {
+		t.Errorf("CheckTokenSegments got %v", err)
+	}
+
+	if _, err := ParseStrict(SigningMethodGmSM2, tokenString, publicKey, encoder); err != nil {
+		t.Errorf("ParseStrict got %v", err)
+	}
+
+	allowlist := NewAllowlistParser[*sm2.PrivateKey, *sm2.PublicKey]("GmSM2").WithEncoder(encoder)
+	if _, err := allowlist.Parse(tokenString, publicKey); err != nil {
+		t.Errorf("AllowlistParser Parse got %v", err)
+	}
+
+	multi := NewMultiParser(func(jwt.TokenHeader) (any, error) {
+		return publicKey, nil
+	}, "GmSM2").WithEncoder(encoder)
+	if _, err := multi.Parse(tokenString); err != nil {
+		t.Errorf("MultiParser Parse got %v", err)
+	}
+
+	cached, err := NewCachedParser(SigningMethodGmSM2, publicKey, NewTokenCache(8, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cached.WithEncoder(encoder).Parse(tokenString); err != nil {
+		t.Errorf("CachedParser Parse got %v", err)
+	}
+
+	results := BatchVerify(SigningMethodGmSM2, []BatchItem[*sm2.PublicKey]{{Token: tokenString, Key: publicKey}}, 1, encoder)
+	if results[0].Err != nil {
+		t.Errorf("BatchVerify got %v", results[0].Err)
+	}
+
+	// the segments with an extra padding are refused
+	if err := CheckTokenSegments(tokenString+"=", encoder); err != ErrTokenSegmentsInvalid {
+		t.Errorf("CheckTokenSegments got %v, want %v", err, ErrTokenSegmentsInvalid)
+	}
+}
@@ -0,0 +1,120 @@
+package jwtgrpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/deatil/go-jwt/jwt"
+)
+
+// Credentials are the per-RPC credentials signing short-lived tokens.
+// A token is reused until half of the ttl is passed.
+type Credentials[S any, V any] struct {
+	method jwt.JWT[S, V]
+	key    S
+	ttl    time.Duration
+	kid    string
+	claims map[string]any
+	secure bool
+
+	mu      sync.Mutex
+	token   string
+	refresh time.Time
+
+	// now is the clock of the token refresh.
+	now func() time.Time
+}
+
+// NewCredentials returns the Credentials signing tokens with the key,
+// the tokens expire after the ttl.
+func NewCredentials[S any, V any](method jwt.JWT[S, V], key S, ttl time.Duration) *Credentials[S, V] {
+	return &Credentials[S, V]{
+		method: method,
+		key:    key,
+		ttl:    ttl,
+		secure: true,
+		now:    time.Now,
+	}
+}
+
+// WithKid sets the kid header of the tokens.
+func (c *Credentials[S, V]) WithKid(kid string) *Credentials[S, V] {
+	c.kid = kid
+	return c
+}
+
+// WithClaims sets the claims of the tokens, such as iss, sub and aud.
+// The iat, exp and jti claims are set for every token.
+func (c *Credentials[S, V]) WithClaims(claims map[string]any) *Credentials[S, V] {
+	c.claims = claims
+	return c
+}
+
+// WithTransportSecurity sets whether the credentials require transport
+// security, it is true by default. Only disable it for tests.
+func (c *Credentials[S, V]) WithTransportSecurity(secure bool) *Credentials[S, V] {
+	c.secure = secure
+	return c
+}
+
+// GetRequestMetadata returns the authorization metadata of the call.
+func (c *Credentials[S, V]) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		authorizationKey: "Bearer " + token,
+	}, nil
+}
+
+// RequireTransportSecurity reports whether the credentials require
+// transport security.
+func (c *Credentials[S, V]) RequireTransportSecurity() bool {
+	return c.secure
+}
+
+// Token returns the cached token, or signs a new token.
+func (c *Credentials[S, V]) Token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.token != "" && now.Before(c.refresh) {
+		return c.token, nil
+	}
+
+	var jti [16]byte
+	if _, err := rand.Read(jti[:]); err != nil {
+		return "", err
+	}
+
+	claims := make(map[string]any, len(c.claims)+3)
+	for k, v := range c.claims {
+		claims[k] = v
+	}
+
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(c.ttl).Unix()
+	claims["jti"] = hex.EncodeToString(jti[:])
+
+	header := jwt.TokenHeader{
+		Typ: "JWT",
+		Alg: c.method.New().Alg(),
+		Kid: c.kid,
+	}
+
+	token, err := c.method.New().SignWithHeader(header, claims, c.key)
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token
+	c.refresh = now.Add(c.ttl / 2)
+
+	return token, nil
+}
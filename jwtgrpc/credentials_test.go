@@ -0,0 +1,87 @@
+package jwtgrpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deatil/go-jwt/jwt"
+
+	jwtgm "github.com/deatil/go-jwt-gm/jwt"
+)
+
+func Test_Credentials(t *testing.T) {
+	key := []byte("test-key")
+	now := time.Unix(1700000000, 0)
+
+	c := NewCredentials(jwtgm.SigningMethodHSM3, key, time.Minute).
+		WithKid("k1").
+		WithClaims(map[string]any{
+			"sub": "client",
+		})
+	c.now = func() time.Time {
+		return now
+	}
+
+	if !c.RequireTransportSecurity() {
+		t.Error("RequireTransportSecurity got false")
+	}
+
+	md, err := c.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenString, ok := strings.CutPrefix(md["authorization"], "Bearer ")
+	if !ok {
+		t.Fatalf("GetRequestMetadata got %v", md)
+	}
+
+	parsed, err := jwtgm.SigningMethodHSM3.New().Parse(tokenString, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, err := parsed.GetHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Kid != "k1" || header.Alg != "HSM3" {
+		t.Errorf("GetHeader got %+v", header)
+	}
+
+	var claims jwt.RegisteredClaims
+	if err := parsed.GetClaimsT(&claims); err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.Subject != "client" || claims.ID == "" {
+		t.Errorf("GetClaims got %+v", claims)
+	}
+	if claims.IssuedAt.Unix() != now.Unix() || claims.ExpiresAt.Unix() != now.Add(time.Minute).Unix() {
+		t.Errorf("GetClaims iat %v exp %v", claims.IssuedAt, claims.ExpiresAt)
+	}
+
+	// the token is reused in the first half of the ttl
+	now = now.Add(20 * time.Second)
+
+	token2, err := c.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token2 != tokenString {
+		t.Error("Token is not reused")
+	}
+
+	now = now.Add(20 * time.Second)
+
+	token3, err := c.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token3 == tokenString {
+		t.Error("Token is not refreshed")
+	}
+}
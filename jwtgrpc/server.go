@@ -0,0 +1,250 @@
+// Package jwtgrpc provides gRPC interceptors and per-RPC credentials
+// to authenticate calls with the go-jwt-gm signing methods.
+package jwtgrpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/deatil/go-jwt/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	jwtgm "github.com/deatil/go-jwt-gm/jwt"
+)
+
+// the metadata key of the bearer token
+const authorizationKey = "authorization"
+
+var (
+	ErrTokenMissing = errors.New("go-jwt: token missing")
+
+	// the claims errors of jwtgm.ValidateClaims
+	ErrTokenExpired     = jwtgm.ErrTokenExpired
+	ErrTokenNotValidYet = jwtgm.ErrTokenNotValidYet
+	ErrTokenUsedEarly   = jwtgm.ErrTokenUsedEarly
+	ErrAudienceInvalid  = jwtgm.ErrAudienceInvalid
+	ErrIssuerInvalid    = jwtgm.ErrIssuerInvalid
+)
+
+// KeyFunc returns the verify key of the token header, the kid
+// of the header can select the key.
+type KeyFunc[V any] func(ctx context.Context, header jwt.TokenHeader) (V, error)
+
+// StaticKey returns the KeyFunc of one key.
+func StaticKey[V any](key V) KeyFunc[V] {
+	return func(context.Context, jwt.TokenHeader) (V, error) {
+		return key, nil
+	}
+}
+
+// Interceptor verifies the bearer tokens of the incoming metadata with
+// the signing method, and stores the claims of type C in the context.
+type Interceptor[S any, V any, C any] struct {
+	method     jwt.JWT[S, V]
+	keyFunc    KeyFunc[V]
+	validators []func(ctx context.Context, claims *C) error
+	skip       func(fullMethod string) bool
+	audience   string
+	issuer     string
+	leeway     int64
+	encoder    jwt.IEncoder
+
+	// now is the clock of the claims checks.
+	now func() time.Time
+}
+
+// New returns the Interceptor of the signing method and the key source.
+func New[S any, V any, C any](method jwt.JWT[S, V], keyFunc KeyFunc[V]) *Interceptor[S, V, C] {
+	return &Interceptor[S, V, C]{
+		method:  method,
+		keyFunc: keyFunc,
+		now:     time.Now,
+	}
+}
+
+// WithEncoder sets the encoder of the method, the encoder of the method
+// can not be read, a method with its own encoder sets it here.
+func (i *Interceptor[S, V, C]) WithEncoder(encoder jwt.IEncoder) *Interceptor[S, V, C] {
+	i.encoder = encoder
+	return i
+}
+
+// WithValidator adds a validator of the claims. A validator returns
+// a status error to set the code, others are Unauthenticated.
+func (i *Interceptor[S, V, C]) WithValidator(validator func(ctx context.Context, claims *C) error) *Interceptor[S, V, C] {
+	i.validators = append(i.validators, validator)
+	return i
+}
+
+// WithSkip sets the methods called without authentication,
+// such as the health checks.
+func (i *Interceptor[S, V, C]) WithSkip(skip func(fullMethod string) bool) *Interceptor[S, V, C] {
+	i.skip = skip
+	return i
+}
+
+// WithAudience requires the aud claim has the audience.
+func (i *Interceptor[S, V, C]) WithAudience(audience string) *Interceptor[S, V, C] {
+	i.audience = audience
+	return i
+}
+
+// WithIssuer requires the iss claim is the issuer.
+func (i *Interceptor[S, V, C]) WithIssuer(issuer string) *Interceptor[S, V, C] {
+	i.issuer = issuer
+	return i
+}
+
+// WithLeeway sets the leeway seconds of the exp, nbf and iat claims.
+func (i *Interceptor[S, V, C]) WithLeeway(leeway int64) *Interceptor[S, V, C] {
+	i.leeway = leeway
+	return i
+}
+
+// Unary returns the unary server interceptor.
+func (i *Interceptor[S, V, C]) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if i.skip != nil && i.skip(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := i.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns the stream server interceptor.
+func (i *Interceptor[S, V, C]) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if i.skip != nil && i.skip(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := i.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{
+			ServerStream: ss,
+			ctx:          ctx,
+		})
+	}
+}
+
+// authenticate returns the context with the claims, or a status error.
+func (i *Interceptor[S, V, C]) authenticate(ctx context.Context) (context.Context, error) {
+	token, claims, err := i.Authenticate(ctx)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return NewContext(ctx, token, claims), nil
+}
+
+// Authenticate verifies and validates the token of the incoming metadata.
+func (i *Interceptor[S, V, C]) Authenticate(ctx context.Context) (*jwt.Token, *C, error) {
+	tokenString := tokenFromMetadata(ctx)
+	if tokenString == "" {
+		return nil, nil, ErrTokenMissing
+	}
+
+	var encoders []jwt.IEncoder
+	if i.encoder != nil {
+		encoders = append(encoders, i.encoder)
+	}
+
+	if err := jwtgm.CheckTokenSegments(tokenString, encoders...); err != nil {
+		return nil, nil, err
+	}
+
+	header, err := jwt.GetTokenHeader(tokenString, encoders...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := i.keyFunc(ctx, header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token, err := jwtgm.ParseStrict(i.method, tokenString, key, encoders...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := jwtgm.ValidateClaims(token, i.now(), i.leeway, i.audience, i.issuer); err != nil {
+		return nil, nil, err
+	}
+
+	claims := new(C)
+	if err := token.GetClaimsT(claims); err != nil {
+		return nil, nil, err
+	}
+
+	for _, validator := range i.validators {
+		if err := validator(ctx, claims); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return token, claims, nil
+}
+
+// tokenFromMetadata returns the token of the "authorization: Bearer" metadata.
+func tokenFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(authorizationKey)
+	if len(values) == 0 {
+		return ""
+	}
+
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// serverStream is the ServerStream with the authenticated context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// NewContext returns the context with the token and the claims.
+func NewContext[C any](ctx context.Context, token *jwt.Token, claims *C) context.Context {
+	return jwtgm.NewContext(ctx, token, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated call.
+func ClaimsFromContext[C any](ctx context.Context) (*C, bool) {
+	return jwtgm.ClaimsFromContext[C](ctx)
+}
+
+// TokenFromContext returns the token of the authenticated call.
+func TokenFromContext(ctx context.Context) (*jwt.Token, bool) {
+	return jwtgm.TokenFromContext(ctx)
+}
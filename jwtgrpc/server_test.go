@@ -0,0 +1,281 @@
+package jwtgrpc
+
+import (
+	"context"
+	"crypto/rand"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	jwtgm "github.com/deatil/go-jwt-gm/jwt"
+)
+
+type testClaims struct {
+	jwt.RegisteredClaims
+
+	Role string `json:"role"`
+}
+
+// claimsRecorder records the claims of the authenticated calls.
+type claimsRecorder struct {
+	mu     sync.Mutex
+	claims []*testClaims
+}
+
+func (r *claimsRecorder) record(ctx context.Context) {
+	claims, _ := ClaimsFromContext[testClaims](ctx)
+
+	r.mu.Lock()
+	r.claims = append(r.claims, claims)
+	r.mu.Unlock()
+}
+
+func (r *claimsRecorder) last() *testClaims {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.claims) == 0 {
+		return nil
+	}
+
+	return r.claims[len(r.claims)-1]
+}
+
+// startServer starts the health server with the interceptor on bufconn.
+func startServer[S any, V any](t *testing.T, i *Interceptor[S, V, testClaims], recorder *claimsRecorder) *bufconn.Listener {
+	lis := bufconn.Listen(1 << 20)
+
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(i.Unary(), func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			recorder.record(ctx)
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(i.Stream(), func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			recorder.record(ss.Context())
+			return handler(srv, ss)
+		}),
+	)
+
+	healthpb.RegisterHealthServer(s, health.NewServer())
+
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	return lis
+}
+
+func dial(t *testing.T, lis *bufconn.Listener, creds credentials.PerRPCCredentials) healthpb.HealthClient {
+	opts := []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if creds != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(creds))
+	}
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return healthpb.NewHealthClient(conn)
+}
+
+func Test_Interceptor_GmSM2(t *testing.T) {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &claimsRecorder{}
+
+	i := New[*sm2.PrivateKey, *sm2.PublicKey, testClaims](jwtgm.SigningMethodGmSM2, StaticKey(&privateKey.PublicKey)).
+		WithAudience("health")
+	lis := startServer(t, i, recorder)
+
+	creds := NewCredentials(jwtgm.SigningMethodGmSM2, privateKey, time.Minute).
+		WithClaims(map[string]any{
+			"aud":  "health",
+			"sub":  "client",
+			"role": "admin",
+		}).
+		WithTransportSecurity(false)
+
+	client := dial(t, lis, creds)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	claims := recorder.last()
+	if claims == nil || claims.Subject != "client" || claims.Role != "admin" {
+		t.Errorf("Check claims got %+v", claims)
+	}
+
+	// stream
+	streamCtx, streamCancel := context.WithCancel(ctx)
+	defer streamCancel()
+
+	stream, err := client.Watch(streamCtx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Watch status got %v", resp.Status)
+	}
+
+	claims = recorder.last()
+	if claims == nil || claims.Subject != "client" {
+		t.Errorf("Watch claims got %+v", claims)
+	}
+
+	streamCancel()
+
+	// without credentials
+	_, err = dial(t, lis, nil).Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Check got %v, want %v", err, codes.Unauthenticated)
+	}
+
+	stream, err = dial(t, lis, nil).Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Watch got %v, want %v", err, codes.Unauthenticated)
+	}
+}
+
+func Test_Interceptor_HSM3(t *testing.T) {
+	key := []byte("test-key")
+	recorder := &claimsRecorder{}
+
+	i := New[[]byte, []byte, testClaims](jwtgm.SigningMethodHSM3, StaticKey(key)).
+		WithIssuer("issuer").
+		WithValidator(func(ctx context.Context, claims *testClaims) error {
+			if claims.Role != "admin" {
+				return status.Error(codes.PermissionDenied, "not admin")
+			}
+
+			return nil
+		})
+	lis := startServer(t, i, recorder)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		key    []byte
+		claims map[string]any
+		code   codes.Code
+	}{
+		{"valid", key, map[string]any{"iss": "issuer", "role": "admin"}, codes.OK},
+		{"wrong key", []byte("other-key"), map[string]any{"iss": "issuer", "role": "admin"}, codes.Unauthenticated},
+		{"issuer", key, map[string]any{"iss": "other", "role": "admin"}, codes.Unauthenticated},
+		{"validator", key, map[string]any{"iss": "issuer", "role": "user"}, codes.PermissionDenied},
+	}
+
+	for _, td := range tests {
+		creds := NewCredentials(jwtgm.SigningMethodHSM3, td.key, time.Minute).
+			WithClaims(td.claims).
+			WithTransportSecurity(false)
+
+		_, err := dial(t, lis, creds).Check(ctx, &healthpb.HealthCheckRequest{})
+		if status.Code(err) != td.code {
+			t.Errorf("%s Check got %v, want %v", td.name, err, td.code)
+		}
+	}
+}
+
+func Test_Interceptor_Skip(t *testing.T) {
+	recorder := &claimsRecorder{}
+
+	i := New[[]byte, []byte, testClaims](jwtgm.SigningMethodHSM3, StaticKey([]byte("test-key"))).
+		WithSkip(func(fullMethod string) bool {
+			return fullMethod == healthpb.Health_Check_FullMethodName
+		})
+	lis := startServer(t, i, recorder)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := dial(t, lis, nil).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if claims := recorder.last(); claims != nil {
+		t.Errorf("Check claims got %+v", claims)
+	}
+}
+
+func Test_Interceptor_Authenticate(t *testing.T) {
+	key := []byte("test-key")
+	now := time.Unix(1700000000, 0)
+
+	i := New[[]byte, []byte, testClaims](jwtgm.SigningMethodHSM3, StaticKey(key))
+	i.now = func() time.Time {
+		return now
+	}
+
+	sign := func(claims map[string]any) string {
+		tokenString, err := jwtgm.SigningMethodHSM3.New().Sign(claims, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return tokenString
+	}
+
+	tests := []struct {
+		name string
+		auth string
+		err  error
+	}{
+		{"missing", "", ErrTokenMissing},
+		{"scheme", "Basic " + sign(map[string]any{}), ErrTokenMissing},
+		{"expired", "Bearer " + sign(map[string]any{"exp": now.Add(-time.Minute).Unix()}), ErrTokenExpired},
+		{"nbf", "Bearer " + sign(map[string]any{"nbf": now.Add(time.Minute).Unix()}), ErrTokenNotValidYet},
+		{"iat", "Bearer " + sign(map[string]any{"iat": now.Add(time.Minute).Unix()}), ErrTokenUsedEarly},
+		{"valid", "bearer " + sign(map[string]any{"exp": now.Add(time.Minute).Unix()}), nil},
+	}
+
+	for _, td := range tests {
+		ctx := context.Background()
+		if td.auth != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", td.auth))
+		}
+
+		_, _, err := i.Authenticate(ctx)
+		if err != td.err {
+			t.Errorf("%s Authenticate got %v, want %v", td.name, err, td.err)
+		}
+	}
+}
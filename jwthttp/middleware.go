@@ -0,0 +1,259 @@
+// Package jwthttp provides net/http middleware to authenticate requests
+// with bearer tokens of the go-jwt-gm signing methods.
+package jwthttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/deatil/go-jwt/jwt"
+
+	jwtgm "github.com/deatil/go-jwt-gm/jwt"
+)
+
+var (
+	ErrTokenMissing = errors.New("go-jwt: token missing")
+
+	// the claims errors of jwtgm.ValidateClaims
+	ErrTokenExpired     = jwtgm.ErrTokenExpired
+	ErrTokenNotValidYet = jwtgm.ErrTokenNotValidYet
+	ErrTokenUsedEarly   = jwtgm.ErrTokenUsedEarly
+	ErrAudienceInvalid  = jwtgm.ErrAudienceInvalid
+	ErrIssuerInvalid    = jwtgm.ErrIssuerInvalid
+)
+
+// KeyFunc returns the verify key of the token header, the kid
+// of the header can select the key.
+type KeyFunc[V any] func(r *http.Request, header jwt.TokenHeader) (V, error)
+
+// StaticKey returns the KeyFunc of one key.
+func StaticKey[V any](key V) KeyFunc[V] {
+	return func(*http.Request, jwt.TokenHeader) (V, error) {
+		return key, nil
+	}
+}
+
+// Extractor returns the token of the request, or empty when not found.
+type Extractor func(r *http.Request) string
+
+// FromAuthHeader extracts the token from the "Authorization: Bearer" header.
+func FromAuthHeader(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+
+	scheme, token, ok := strings.Cut(auth, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// FromCookie extracts the token from the cookie.
+func FromCookie(name string) Extractor {
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+
+		return cookie.Value
+	}
+}
+
+// FromQuery extracts the token from the query parameter.
+func FromQuery(name string) Extractor {
+	return func(r *http.Request) string {
+		return r.URL.Query().Get(name)
+	}
+}
+
+// ErrorHandler writes the response of the failed authentication.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// DefaultErrorHandler responds 401 with the RFC 6750 challenge.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	if err == ErrTokenMissing {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+	} else {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// Middleware verifies the bearer tokens of the requests with the signing
+// method, and stores the claims of type C in the request context.
+type Middleware[S any, V any, C any] struct {
+	method       jwt.JWT[S, V]
+	keyFunc      KeyFunc[V]
+	extractors   []Extractor
+	errorHandler ErrorHandler
+	validators   []func(r *http.Request, claims *C) error
+	audience     string
+	issuer       string
+	leeway       int64
+	optional     bool
+	encoder      jwt.IEncoder
+
+	// now is the time exp, nbf and iat are checked at.
+	now func() time.Time
+}
+
+// New returns the Middleware of the signing method and the key source.
+// The token is extracted from the Authorization header by default.
+func New[S any, V any, C any](method jwt.JWT[S, V], keyFunc KeyFunc[V]) *Middleware[S, V, C] {
+	return &Middleware[S, V, C]{
+		method:       method,
+		keyFunc:      keyFunc,
+		extractors:   []Extractor{FromAuthHeader},
+		errorHandler: DefaultErrorHandler,
+		now:          time.Now,
+	}
+}
+
+// WithExtractors sets the extractors, the first found token is used.
+func (m *Middleware[S, V, C]) WithExtractors(extractors ...Extractor) *Middleware[S, V, C] {
+	m.extractors = extractors
+	return m
+}
+
+// WithErrorHandler sets the handler of the failed authentication.
+func (m *Middleware[S, V, C]) WithErrorHandler(handler ErrorHandler) *Middleware[S, V, C] {
+	m.errorHandler = handler
+	return m
+}
+
+// WithEncoder sets the encoder of the method, the encoder of the method
+// can not be read, a method with its own encoder sets it here.
+func (m *Middleware[S, V, C]) WithEncoder(encoder jwt.IEncoder) *Middleware[S, V, C] {
+	m.encoder = encoder
+	return m
+}
+
+// WithValidator adds a validator of the claims.
+func (m *Middleware[S, V, C]) WithValidator(validator func(r *http.Request, claims *C) error) *Middleware[S, V, C] {
+	m.validators = append(m.validators, validator)
+	return m
+}
+
+// WithAudience requires the aud claim has the audience.
+func (m *Middleware[S, V, C]) WithAudience(audience string) *Middleware[S, V, C] {
+	m.audience = audience
+	return m
+}
+
+// WithIssuer requires the iss claim is the issuer.
+func (m *Middleware[S, V, C]) WithIssuer(issuer string) *Middleware[S, V, C] {
+	m.issuer = issuer
+	return m
+}
+
+// WithLeeway sets the leeway seconds of the exp, nbf and iat claims.
+func (m *Middleware[S, V, C]) WithLeeway(leeway int64) *Middleware[S, V, C] {
+	m.leeway = leeway
+	return m
+}
+
+// WithOptional passes the requests without a token to the next handler
+// with no claims in the context. The requests with an invalid token
+// are still refused.
+func (m *Middleware[S, V, C]) WithOptional(optional bool) *Middleware[S, V, C] {
+	m.optional = optional
+	return m
+}
+
+// Handler returns the handler authenticating the requests before next.
+func (m *Middleware[S, V, C]) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, claims, err := m.Authenticate(r)
+		if err != nil {
+			if err == ErrTokenMissing && m.optional {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			m.errorHandler(w, r, err)
+			return
+		}
+
+		ctx := NewContext(r.Context(), token, claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Authenticate extracts, verifies and validates the token of the request.
+func (m *Middleware[S, V, C]) Authenticate(r *http.Request) (*jwt.Token, *C, error) {
+	tokenString := m.extract(r)
+	if tokenString == "" {
+		return nil, nil, ErrTokenMissing
+	}
+
+	var encoders []jwt.IEncoder
+	if m.encoder != nil {
+		encoders = append(encoders, m.encoder)
+	}
+
+	if err := jwtgm.CheckTokenSegments(tokenString, encoders...); err != nil {
+		return nil, nil, err
+	}
+
+	header, err := jwt.GetTokenHeader(tokenString, encoders...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := m.keyFunc(r, header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token, err := jwtgm.ParseStrict(m.method, tokenString, key, encoders...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := jwtgm.ValidateClaims(token, m.now(), m.leeway, m.audience, m.issuer); err != nil {
+		return nil, nil, err
+	}
+
+	claims := new(C)
+	if err := token.GetClaimsT(claims); err != nil {
+		return nil, nil, err
+	}
+
+	for _, validator := range m.validators {
+		if err := validator(r, claims); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return token, claims, nil
+}
+
+func (m *Middleware[S, V, C]) extract(r *http.Request) string {
+	for _, extractor := range m.extractors {
+		if token := extractor(r); token != "" {
+			return token
+		}
+	}
+
+	return ""
+}
+
+// NewContext returns the context with the token and the claims.
+func NewContext[C any](ctx context.Context, token *jwt.Token, claims *C) context.Context {
+	return jwtgm.NewContext(ctx, token, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated request.
+func ClaimsFromContext[C any](ctx context.Context) (*C, bool) {
+	return jwtgm.ClaimsFromContext[C](ctx)
+}
+
+// TokenFromContext returns the token of the authenticated request.
+func TokenFromContext(ctx context.Context) (*jwt.Token, bool) {
+	return jwtgm.TokenFromContext(ctx)
+}
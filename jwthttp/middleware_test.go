@@ -0,0 +1,314 @@
+package jwthttp
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/deatil/go-cryptobin/gm/sm2"
+	"github.com/deatil/go-jwt/jwt"
+
+	jwtgm "github.com/deatil/go-jwt-gm/jwt"
+)
+
+type testClaims struct {
+	jwt.RegisteredClaims
+
+	Role string `json:"role"`
+}
+
+func newTestKey(t *testing.T) *sm2.PrivateKey {
+	privateKey, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return privateKey
+}
+
+func signTestToken(t *testing.T, privateKey *sm2.PrivateKey, claims map[string]any) string {
+	tokenString, err := jwtgm.SigningMethodGmSM2.New().Sign(claims, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tokenString
+}
+
+// claimsHandler responds the sub claim, or "anonymous".
+var claimsHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext[testClaims](r.Context())
+	if !ok {
+		w.Write([]byte("anonymous"))
+		return
+	}
+
+	if _, ok := TokenFromContext(r.Context()); !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write([]byte(claims.Subject + ":" + claims.Role))
+})
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+func Test_Middleware(t *testing.T) {
+	privateKey := newTestKey(t)
+	tokenString := signTestToken(t, privateKey, map[string]any{
+		"sub":  "foo",
+		"role": "admin",
+	})
+
+	m := New[*sm2.PrivateKey, *sm2.PublicKey, testClaims](jwtgm.SigningMethodGmSM2, StaticKey(&privateKey.PublicKey)).
+		WithExtractors(FromAuthHeader, FromCookie("token"), FromQuery("token"))
+	h := m.Handler(claimsHandler)
+
+	requests := map[string]*http.Request{}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+tokenString)
+	requests["header"] = r
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "bearer "+tokenString)
+	requests["header lower"] = r
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "token", Value: tokenString})
+	requests["cookie"] = r
+
+	requests["query"] = httptest.NewRequest("GET", "/?token="+tokenString, nil)
+
+	for name, r := range requests {
+		w := serve(h, r)
+		if w.Code != http.StatusOK || w.Body.String() != "foo:admin" {
+			t.Errorf("%s got %d %q", name, w.Code, w.Body.String())
+		}
+	}
+}
+
+// paddedEncoder is the encoder of the padded base64url segments.
+type paddedEncoder struct {
+	jwt.JoseEncoder
+}
+
+func (e *paddedEncoder) Base64URLEncode(data []byte) (string, error) {
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
+func (e *paddedEncoder) Base64URLDecode(data string) ([]byte, error) {
+	return base64.URLEncoding.DecodeString(data)
+}
+
+func Test_Middleware_Encoder(t *testing.T) {
+	privateKey := newTestKey(t)
+	encoder := &paddedEncoder{}
+
+	method := jwt.NewJWT[*sm2.PrivateKey, *sm2.PublicKey](jwtgm.SigningGmSM2, encoder)
+
+	tokenString, err := method.New().Sign(map[string]any{"sub": "foo", "role": "admin"}, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New[*sm2.PrivateKey, *sm2.PublicKey, testClaims](method, StaticKey(&privateKey.PublicKey))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+tokenString)
+
+	// the padded segments are refused by the default encoder
+	if w := serve(m.Handler(claimsHandler), r); w.Code != http.StatusUnauthorized {
+		t.Errorf("got %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	m.WithEncoder(encoder)
+	if w := serve(m.Handler(claimsHandler), r); w.Code != http.StatusOK || w.Body.String() != "foo:admin" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func Test_Middleware_Refused(t *testing.T) {
+	privateKey := newTestKey(t)
+	otherKey := newTestKey(t)
+
+	now := time.Unix(1700000000, 0)
+
+	m := New[*sm2.PrivateKey, *sm2.PublicKey, testClaims](jwtgm.SigningMethodGmSM2, StaticKey(&privateKey.PublicKey)).
+		WithAudience("example.com").
+		WithIssuer("issuer")
+	m.now = func() time.Time {
+		return now
+	}
+
+	h := m.Handler(claimsHandler)
+
+	valid := map[string]any{
+		"aud": "example.com",
+		"iss": "issuer",
+		"exp": now.Add(time.Minute).Unix(),
+	}
+
+	with := func(key string, value any) map[string]any {
+		claims := map[string]any{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		claims[key] = value
+
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"missing", "", ErrTokenMissing},
+		{"wrong key", signTestToken(t, otherKey, valid), jwt.ErrJWTVerifyFail},
+		{"expired", signTestToken(t, privateKey, with("exp", now.Add(-time.Minute).Unix())), ErrTokenExpired},
+		{"nbf", signTestToken(t, privateKey, with("nbf", now.Add(time.Minute).Unix())), ErrTokenNotValidYet},
+		{"iat", signTestToken(t, privateKey, with("iat", now.Add(time.Minute).Unix())), ErrTokenUsedEarly},
+		{"aud", signTestToken(t, privateKey, with("aud", "other.com")), ErrAudienceInvalid},
+		{"iss", signTestToken(t, privateKey, with("iss", "other")), ErrIssuerInvalid},
+		{"valid", signTestToken(t, privateKey, with("nbf", now.Unix())), nil},
+	}
+
+	for _, td := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if td.token != "" {
+			r.Header.Set("Authorization", "Bearer "+td.token)
+		}
+
+		_, _, err := m.Authenticate(r)
+		if err != td.err {
+			t.Errorf("%s Authenticate got %v, want %v", td.name, err, td.err)
+		}
+
+		w := serve(h, r)
+		if td.err == nil {
+			if w.Code != http.StatusOK {
+				t.Errorf("%s got %d", td.name, w.Code)
+			}
+			continue
+		}
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s got %d, want %d", td.name, w.Code, http.StatusUnauthorized)
+		}
+
+		challenge := `Bearer error="invalid_token"`
+		if td.err == ErrTokenMissing {
+			challenge = `Bearer`
+		}
+
+		if got := w.Header().Get("WWW-Authenticate"); got != challenge {
+			t.Errorf("%s WWW-Authenticate got %q, want %q", td.name, got, challenge)
+		}
+	}
+}
+
+func Test_Middleware_Optional(t *testing.T) {
+	privateKey := newTestKey(t)
+	otherKey := newTestKey(t)
+
+	h := New[*sm2.PrivateKey, *sm2.PublicKey, testClaims](jwtgm.SigningMethodGmSM2, StaticKey(&privateKey.PublicKey)).
+		WithOptional(true).
+		Handler(claimsHandler)
+
+	w := serve(h, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "anonymous" {
+		t.Errorf("no token got %d %q", w.Code, w.Body.String())
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+signTestToken(t, otherKey, map[string]any{"sub": "foo"}))
+
+	w = serve(h, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("invalid token got %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func Test_Middleware_KeyFunc(t *testing.T) {
+	keys := map[string]*sm2.PrivateKey{
+		"k1": newTestKey(t),
+		"k2": newTestKey(t),
+	}
+
+	errKeyNotFound := errors.New("key not found")
+
+	keyFunc := func(r *http.Request, header jwt.TokenHeader) (*sm2.PublicKey, error) {
+		key, ok := keys[header.Kid]
+		if !ok {
+			return nil, errKeyNotFound
+		}
+
+		return &key.PublicKey, nil
+	}
+
+	var handled error
+	m := New[*sm2.PrivateKey, *sm2.PublicKey, testClaims](jwtgm.SigningMethodGmSM2, keyFunc).
+		WithValidator(func(r *http.Request, claims *testClaims) error {
+			if claims.Role != "admin" {
+				return errors.New("not admin")
+			}
+
+			return nil
+		}).
+		WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			handled = err
+			w.WriteHeader(http.StatusForbidden)
+		})
+	h := m.Handler(claimsHandler)
+
+	sign := func(kid string, role string) string {
+		header := jwt.TokenHeader{Typ: "JWT", Alg: "GmSM2", Kid: kid}
+		claims := map[string]any{"sub": kid, "role": role}
+
+		key := keys[kid]
+		if key == nil {
+			key = keys["k1"]
+		}
+
+		tokenString, err := jwtgm.SigningMethodGmSM2.New().SignWithHeader(header, claims, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return tokenString
+	}
+
+	for _, kid := range []string{"k1", "k2"} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+sign(kid, "admin"))
+
+		w := serve(h, r)
+		if w.Code != http.StatusOK || w.Body.String() != kid+":admin" {
+			t.Errorf("%s got %d %q", kid, w.Code, w.Body.String())
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+sign("k3", "admin"))
+
+	if w := serve(h, r); w.Code != http.StatusForbidden || handled != errKeyNotFound {
+		t.Errorf("unknown kid got %d, %v", w.Code, handled)
+	}
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+sign("k1", "user"))
+
+	if w := serve(h, r); w.Code != http.StatusForbidden || handled == nil || handled.Error() != "not admin" {
+		t.Errorf("validator got %d, %v", w.Code, handled)
+	}
+}